@@ -0,0 +1,181 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package consensus
+
+import (
+	"sync"
+	"time"
+
+	"github.com/icon-project/goloop/module"
+)
+
+// sendPriority orders the kinds of messages a peer's sender loop can pick
+// from: votes matter for liveness and must never wait behind a bulk block
+// part stream, round-state keeps peers' masks fresh, and block parts are
+// pure bulk data that can tolerate being throttled first.
+type sendPriority int
+
+const (
+	priorityHigh sendPriority = iota
+	priorityMedium
+	priorityBulk
+	numSendPriorities
+)
+
+// priorityShare is the fraction of a peer's configured send budget that is
+// reserved for each priority tier. Unused budget in a higher tier is not
+// available to lower tiers; this keeps a saturated bulk stream from ever
+// starving vote gossip, at the cost of some budget going idle.
+var priorityShare = [numSendPriorities]float64{
+	priorityHigh:   0.5,
+	priorityMedium: 0.2,
+	priorityBulk:   0.3,
+}
+
+func priorityOf(proto module.ProtocolInfo) sendPriority {
+	switch proto {
+	case protoVoteList, protoHasVote:
+		return priorityHigh
+	case protoRoundState, protoHasBlockPart:
+		return priorityMedium
+	case protoBlockPart:
+		return priorityBulk
+	default:
+		return priorityBulk
+	}
+}
+
+// tokenBucket is a simple byte-budget limiter: it refills continuously at
+// bps bytes/sec up to a one-second burst, and take() reports how long the
+// caller must wait before it would be allowed to send n bytes.
+type tokenBucket struct {
+	mu     sync.Mutex
+	bps    float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(bps float64) *tokenBucket {
+	return &tokenBucket{bps: bps, tokens: bps, last: time.Now()}
+}
+
+func (b *tokenBucket) setBPS(bps float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bps = bps
+}
+
+func (b *tokenBucket) refillLocked(now time.Time) {
+	if b.bps <= 0 {
+		return
+	}
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens += elapsed * b.bps
+	if burst := b.bps; b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = now
+}
+
+// take reports whether n bytes may be sent now. If not, it returns how long
+// the caller should wait before retrying; no tokens are consumed in that case.
+func (b *tokenBucket) take(n int) (bool, time.Duration) {
+	if b.bps <= 0 {
+		return true, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	b.refillLocked(now)
+
+	// A single message can be larger than this bucket's one-second burst
+	// (bps); requiring the full n tokens would then never be satisfied,
+	// since refillLocked caps tokens at bps, and the message would
+	// reschedule forever. Require only up to the burst instead, and let
+	// tokens go negative on an oversized send so the next one still waits
+	// out the real cost.
+	threshold := float64(n)
+	if burst := b.bps; threshold > burst {
+		threshold = burst
+	}
+	if b.tokens >= threshold {
+		b.tokens -= float64(n)
+		return true, 0
+	}
+	deficit := threshold - b.tokens
+	return false, time.Duration(deficit / b.bps * float64(time.Second))
+}
+
+// BandwidthStats reports a peer's observed send behavior, exposed so
+// operators can tell bulk-syncing nodes apart from validators that need a
+// tight vote-gossip budget.
+type BandwidthStats struct {
+	BytesSent int64
+	Drops     int64
+	Sends     int64
+	TotalWait time.Duration
+}
+
+func (s BandwidthStats) AverageWait() time.Duration {
+	if s.Sends == 0 {
+		return 0
+	}
+	return s.TotalWait / time.Duration(s.Sends)
+}
+
+// peerBandwidth holds the per-priority token buckets and stats for a peer.
+type peerBandwidth struct {
+	mu      sync.Mutex
+	buckets [numSendPriorities]*tokenBucket
+	stats   [numSendPriorities]BandwidthStats
+}
+
+func newPeerBandwidth(bps int) *peerBandwidth {
+	pb := &peerBandwidth{}
+	for pr := sendPriority(0); pr < numSendPriorities; pr++ {
+		pb.buckets[pr] = newTokenBucket(float64(bps) * priorityShare[pr])
+	}
+	return pb
+}
+
+func (pb *peerBandwidth) setBPS(bps int) {
+	for pr := sendPriority(0); pr < numSendPriorities; pr++ {
+		pb.buckets[pr].setBPS(float64(bps) * priorityShare[pr])
+	}
+}
+
+// take consumes budget for a message of n bytes at the given priority,
+// recording stats either way.
+func (pb *peerBandwidth) take(pr sendPriority, n int) (bool, time.Duration) {
+	ok, wait := pb.buckets[pr].take(n)
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	if ok {
+		pb.stats[pr].BytesSent += int64(n)
+		pb.stats[pr].Sends++
+	} else {
+		pb.stats[pr].Drops++
+		pb.stats[pr].TotalWait += wait
+	}
+	return ok, wait
+}
+
+func (pb *peerBandwidth) snapshot() [numSendPriorities]BandwidthStats {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	return pb.stats
+}
@@ -0,0 +1,31 @@
+package consensus
+
+import (
+	"testing"
+)
+
+func TestPeerBandwidthPriorityIsolation(t *testing.T) {
+	pb := newPeerBandwidth(1000)
+
+	// Saturate the bulk bucket with a stream of large block-part sends.
+	for i := 0; i < 10; i++ {
+		pb.take(priorityBulk, 1000)
+	}
+
+	if ok, _ := pb.take(priorityBulk, 1000); ok {
+		t.Errorf("expected the bulk bucket to be drained")
+	}
+
+	// A vote message on the high-priority bucket must still go through
+	// even though the bulk bucket for the same peer is saturated.
+	if ok, _ := pb.take(priorityHigh, 10); !ok {
+		t.Errorf("vote-priority send was blocked behind a saturated block-part stream")
+	}
+}
+
+func TestTokenBucketUnlimitedWhenBPSIsZero(t *testing.T) {
+	b := newTokenBucket(0)
+	if ok, wait := b.take(1 << 20); !ok || wait != 0 {
+		t.Errorf("a zero bps bucket should never throttle, got ok=%v wait=%v", ok, wait)
+	}
+}
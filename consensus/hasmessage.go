@@ -0,0 +1,150 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package consensus
+
+import (
+	"fmt"
+
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/module"
+)
+
+// protoHasBlockPart and protoHasVote let a peer announce that it has just
+// accepted a block part or vote, so that neighbors can update their view of
+// that peer's mask without waiting for the next roundStateMessage tick.
+var (
+	protoHasBlockPart = module.ProtocolInfo(0x0005)
+	protoHasVote      = module.ProtocolInfo(0x0006)
+)
+
+type hasBlockPartMessage struct {
+	Height int64
+	Round  int32
+	Index  uint16
+}
+
+func newHasBlockPartMessage() *hasBlockPartMessage {
+	return &hasBlockPartMessage{}
+}
+
+func (m *hasBlockPartMessage) String() string {
+	return fmt.Sprintf("hasBlockPartMessage{Height:%d Round:%d Index:%d}", m.Height, m.Round, m.Index)
+}
+
+func (m *hasBlockPartMessage) verify() error {
+	if m.Height <= 0 {
+		return errors.Errorf("bad height %d", m.Height)
+	}
+	return nil
+}
+
+// hasVoteType distinguishes which per-peer mask a hasVoteMessage refers to.
+type hasVoteType byte
+
+const (
+	hasVotePrevote hasVoteType = iota
+	hasVotePrecommit
+)
+
+type hasVoteMessage struct {
+	Height int64
+	Round  int32
+	Type   hasVoteType
+	Index  uint16
+}
+
+func newHasVoteMessage() *hasVoteMessage {
+	return &hasVoteMessage{}
+}
+
+func (m *hasVoteMessage) String() string {
+	return fmt.Sprintf("hasVoteMessage{Height:%d Round:%d Type:%d Index:%d}", m.Height, m.Round, m.Type, m.Index)
+}
+
+func (m *hasVoteMessage) verify() error {
+	if m.Height <= 0 {
+		return errors.Errorf("bad height %d", m.Height)
+	}
+	return nil
+}
+
+// sendHasBlockPart lets neighbors know that we now have the block part at
+// (height, round, index), so they stop offering it to us and start offering
+// it to others that don't.
+func (s *syncer) sendHasBlockPart(height int64, round int32, index int) {
+	msg := newHasBlockPartMessage()
+	msg.Height = height
+	msg.Round = round
+	msg.Index = uint16(index)
+	bs, err := msgCodec.MarshalToBytes(msg)
+	if err != nil {
+		s.logger.Warnf("sendHasBlockPart: %+v\n", err)
+		return
+	}
+	if err = s.ph.Broadcast(protoHasBlockPart, bs, module.BROADCAST_NEIGHBOR); err != nil {
+		s.logger.Warnf("sendHasBlockPart: %+v\n", err)
+	}
+}
+
+// sendHasVote lets neighbors know that we now have the vote at
+// (height, round, type, index).
+func (s *syncer) sendHasVote(height int64, round int32, vt hasVoteType, index int) {
+	msg := newHasVoteMessage()
+	msg.Height = height
+	msg.Round = round
+	msg.Type = vt
+	msg.Index = uint16(index)
+	bs, err := msgCodec.MarshalToBytes(msg)
+	if err != nil {
+		s.logger.Warnf("sendHasVote: %+v\n", err)
+		return
+	}
+	if err = s.ph.Broadcast(protoHasVote, bs, module.BROADCAST_NEIGHBOR); err != nil {
+		s.logger.Warnf("sendHasVote: %+v\n", err)
+	}
+}
+
+// applyHasBlockPart merges a peer-reported "has" bit into the mask we keep
+// for that peer, without waiting for its next roundStateMessage.
+func (p *peer) applyHasBlockPart(height int64, round int32, index int) {
+	if p.peerRoundState == nil || p.Height != height || p.Round != round {
+		return
+	}
+	if p.BlockPartsMask == nil {
+		return
+	}
+	p.BlockPartsMask.Set(index)
+	p.wakeUp()
+}
+
+func (p *peer) applyHasVote(height int64, round int32, vt hasVoteType, index int) {
+	if p.peerRoundState == nil || p.Height != height || p.Round != round {
+		return
+	}
+	var mask *bitArray
+	switch vt {
+	case hasVotePrevote:
+		mask = p.PrevotesMask
+	case hasVotePrecommit:
+		mask = p.PrecommitsMask
+	}
+	if mask == nil {
+		return
+	}
+	mask.Set(index)
+	p.wakeUp()
+}
@@ -0,0 +1,133 @@
+package snapsync
+
+import (
+	"sync"
+
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/module"
+)
+
+// ErrCanceled is reported to Installer.OnSnapshotEnd when a fetch is
+// canceled by its caller before completion.
+var ErrCanceled = errors.NewBase(errors.InterruptedError, "SnapshotFetchCanceled")
+
+// fetch tracks one in-flight FetchSnapshot call: it asks every known peer
+// for the manifest, keeps the first one for its height (its header is not
+// checked), then round-robins chunk
+// requests across peers so a single slow peer can't serialize the whole
+// download.
+type fetch struct {
+	mgr    *manager
+	height int64
+	ins    Installer
+
+	mu        sync.Mutex
+	manifest  *Manifest
+	have      map[int]bool
+	peers     []module.PeerID
+	nextPeer  int
+	canceled  bool
+	remaining int
+}
+
+func newFetch(mgr *manager, height int64, ins Installer) *fetch {
+	return &fetch{
+		mgr:    mgr,
+		height: height,
+		ins:    ins,
+		have:   make(map[int]bool),
+	}
+}
+
+func (f *fetch) start() (func() bool, error) {
+	f.peers = f.mgr.nm.GetPeers()
+	if len(f.peers) == 0 {
+		return nil, errors.Errorf("no peers to fetch snapshot %d from", f.height)
+	}
+
+	req := struct{ Height int64 }{Height: f.height}
+	bs, err := codec.BC.MarshalToBytes(&req)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.mgr.ph.Broadcast(ProtoManifestRequest, bs, module.BROADCAST_ALL); err != nil {
+		return nil, err
+	}
+
+	return f.cancel, nil
+}
+
+func (f *fetch) cancel() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.canceled {
+		return false
+	}
+	f.canceled = true
+	f.ins.OnSnapshotEnd(ErrCanceled)
+	f.mgr.clearFetch(f)
+	return true
+}
+
+// onManifest is invoked by manager.OnReceive when a manifestMessage for
+// this fetch's height arrives. Only the first one is kept; later ones for
+// the same height are ignored since chunk hashes are already pinned.
+func (f *fetch) onManifest(m *manifestMessage, from module.PeerID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.canceled || f.manifest != nil || m.Height != f.height {
+		return nil
+	}
+	f.manifest = &Manifest{Height: m.Height, Header: m.Header, ChunkHashes: m.ChunkHashes}
+	f.remaining = len(m.ChunkHashes)
+	if err := f.ins.OnManifest(f.manifest); err != nil {
+		return err
+	}
+	for i := range m.ChunkHashes {
+		f.requestChunkLocked(i)
+	}
+	return nil
+}
+
+func (f *fetch) requestChunkLocked(index int) {
+	if len(f.peers) == 0 {
+		return
+	}
+	peer := f.peers[f.nextPeer%len(f.peers)]
+	f.nextPeer++
+	req := chunkRequestMessage{Height: f.height, Index: int32(index)}
+	bs, err := codec.BC.MarshalToBytes(&req)
+	if err != nil {
+		return
+	}
+	_ = f.mgr.ph.Unicast(ProtoChunkRequest, bs, peer)
+}
+
+func (f *fetch) onChunk(m *chunkResponseMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.canceled || f.manifest == nil || m.Height != f.height {
+		return nil
+	}
+	index := int(m.Index)
+	if f.have[index] {
+		return nil
+	}
+	if !verifyChunk(f.manifest, index, m.Data) {
+		// Bad or mismatched chunk from this peer: re-request from the
+		// next one instead of trusting it.
+		f.requestChunkLocked(index)
+		return errors.Errorf("chunk %d failed verification", index)
+	}
+	f.have[index] = true
+	f.remaining--
+	if err := f.ins.OnChunk(index, m.Data); err != nil {
+		return err
+	}
+	if f.remaining == 0 {
+		f.ins.OnSnapshotEnd(nil)
+		f.mgr.clearFetch(f)
+	}
+	return nil
+}
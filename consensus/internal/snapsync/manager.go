@@ -0,0 +1,269 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package snapsync is a sibling of consensus/internal/fastsync for nodes
+// that are too far behind to replay blocks one by one. It pulls a
+// world-state snapshot as a set of independently-hashed chunks and verifies
+// each chunk against the manifest's hash. The manifest's header is passed
+// through as received: this package does not verify it against a commit,
+// and it does not start fastsync for the blocks after the snapshot.
+package snapsync
+
+import (
+	"sync"
+
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/crypto"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+)
+
+var (
+	ProtoManifestRequest  = module.ProtocolInfo(0x0007)
+	ProtoSnapshotManifest = module.ProtocolInfo(0x0008)
+	ProtoChunkRequest     = module.ProtocolInfo(0x0009)
+	ProtoSnapshotChunk    = module.ProtocolInfo(0x000a)
+)
+
+var protocols = []module.ProtocolInfo{
+	ProtoManifestRequest,
+	ProtoSnapshotManifest,
+	ProtoChunkRequest,
+	ProtoSnapshotChunk,
+}
+
+// Manifest describes a snapshot at Height: the header it was taken at (not
+// verified by this package), and the hash of every chunk the state trie was split into, so
+// chunks can be fetched from any peer that advertises them and verified
+// independently of where they came from.
+type Manifest struct {
+	Height      int64
+	Header      []byte
+	ChunkHashes [][]byte
+}
+
+func (m *Manifest) NumChunks() int {
+	return len(m.ChunkHashes)
+}
+
+// Installer receives verified chunks in no particular order and assembles
+// them into restored world state. It is supplied by the caller of
+// FetchSnapshot rather than owned by this package, since installing a
+// snapshot into a BlockManager is outside snapsync's concern.
+type Installer interface {
+	OnManifest(m *Manifest) error
+	OnChunk(index int, data []byte) error
+	OnSnapshotEnd(err error)
+}
+
+type manifestMessage struct {
+	Height      int64
+	Header      []byte
+	ChunkHashes [][]byte
+}
+
+type chunkRequestMessage struct {
+	Height int64
+	Index  int32
+}
+
+type chunkResponseMessage struct {
+	Height int64
+	Index  int32
+	Data   []byte
+}
+
+// Manager drives one snapshot fetch at a time per instance. Server is
+// separate from fetch so a node can serve snapshots to peers while itself
+// not needing one.
+type Manager interface {
+	StartServer()
+	StopServer()
+	// FetchSnapshot requests the manifest for height from all peers,
+	// then pulls every chunk it lists, verifying each against its manifest
+	// hash before handing it to ins. It returns a canceler that stops the
+	// fetch and reports ErrCanceled to ins.OnSnapshotEnd.
+	FetchSnapshot(height int64, ins Installer) (canceler func() bool, err error)
+}
+
+// SnapshotProducer is implemented by the node when it wants to serve
+// snapshots to catching-up peers (archive/validator nodes, gated behind a
+// config flag so light nodes pay nothing for it).
+type SnapshotProducer interface {
+	// Snapshot returns the manifest and a chunk reader for height, or
+	// (nil, nil) if this node does not have or does not serve one.
+	Snapshot(height int64) (*Manifest, ChunkReader, error)
+}
+
+type ChunkReader interface {
+	Chunk(index int) ([]byte, error)
+}
+
+type manager struct {
+	nm       module.NetworkManager
+	ph       module.ProtocolHandler
+	logger   log.Logger
+	producer SnapshotProducer
+
+	mu     sync.Mutex
+	active *fetch
+}
+
+// NewManager creates a snapsync Manager. producer may be nil, in which case
+// this node never serves snapshots to peers (the light-node default).
+func NewManager(nm module.NetworkManager, logger log.Logger, producer SnapshotProducer) (Manager, error) {
+	return &manager{nm: nm, logger: logger, producer: producer}, nil
+}
+
+func (m *manager) StartServer() {
+	ph, err := m.nm.RegisterReactor("consensus.snapsync", m, protocols, 0)
+	if err != nil {
+		m.logger.Warnf("snapsync.StartServer: %+v\n", err)
+		return
+	}
+	m.ph = ph
+}
+
+func (m *manager) StopServer() {
+	m.ph = nil
+}
+
+func (m *manager) FetchSnapshot(height int64, ins Installer) (func() bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.active != nil {
+		return nil, errors.Errorf("a snapshot fetch is already in progress")
+	}
+	f := newFetch(m, height, ins)
+	canceler, err := f.start()
+	if err != nil {
+		return nil, err
+	}
+	m.active = f
+	return canceler, nil
+}
+
+// OnReceive implements module.Reactor: it serves manifest/chunk requests
+// from peers that are catching up, and routes manifest/chunk responses to
+// whichever fetch this node currently has in progress, if any.
+func (m *manager) OnReceive(sp module.ProtocolInfo, bs []byte, id module.PeerID) (bool, error) {
+	switch sp {
+	case ProtoManifestRequest:
+		return m.onManifestRequest(bs, id)
+	case ProtoChunkRequest:
+		return m.onChunkRequest(bs, id)
+	case ProtoSnapshotManifest:
+		var msg manifestMessage
+		if _, err := codec.BC.UnmarshalFromBytes(bs, &msg); err != nil {
+			return false, err
+		}
+		if f := m.currentFetch(); f != nil {
+			return true, f.onManifest(&msg, id)
+		}
+	case ProtoSnapshotChunk:
+		var msg chunkResponseMessage
+		if _, err := codec.BC.UnmarshalFromBytes(bs, &msg); err != nil {
+			return false, err
+		}
+		if f := m.currentFetch(); f != nil {
+			return true, f.onChunk(&msg)
+		}
+	}
+	return false, nil
+}
+
+func (m *manager) currentFetch() *fetch {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.active
+}
+
+func (m *manager) clearFetch(f *fetch) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.active == f {
+		m.active = nil
+	}
+}
+
+func (m *manager) OnFailure(err error, pi module.ProtocolInfo, b []byte) {
+	m.logger.Debugf("snapsync.OnFailure: subprotocol:%v err:%+v\n", pi, err)
+}
+
+func (m *manager) OnJoin(id module.PeerID)  {}
+func (m *manager) OnLeave(id module.PeerID) {}
+
+func (m *manager) onManifestRequest(bs []byte, id module.PeerID) (bool, error) {
+	if m.producer == nil {
+		return false, nil
+	}
+	var req struct{ Height int64 }
+	if _, err := codec.BC.UnmarshalFromBytes(bs, &req); err != nil {
+		return false, err
+	}
+	manifest, _, err := m.producer.Snapshot(req.Height)
+	if err != nil || manifest == nil {
+		return false, err
+	}
+	resp := manifestMessage{Height: manifest.Height, Header: manifest.Header, ChunkHashes: manifest.ChunkHashes}
+	respBS, err := codec.BC.MarshalToBytes(&resp)
+	if err != nil {
+		return false, err
+	}
+	return true, m.ph.Unicast(ProtoSnapshotManifest, respBS, id)
+}
+
+func (m *manager) onChunkRequest(bs []byte, id module.PeerID) (bool, error) {
+	if m.producer == nil {
+		return false, nil
+	}
+	var req chunkRequestMessage
+	if _, err := codec.BC.UnmarshalFromBytes(bs, &req); err != nil {
+		return false, err
+	}
+	manifest, reader, err := m.producer.Snapshot(req.Height)
+	if err != nil || manifest == nil || reader == nil {
+		return false, err
+	}
+	data, err := reader.Chunk(int(req.Index))
+	if err != nil {
+		return false, err
+	}
+	resp := chunkResponseMessage{Height: req.Height, Index: req.Index, Data: data}
+	respBS, err := codec.BC.MarshalToBytes(&resp)
+	if err != nil {
+		return false, err
+	}
+	return true, m.ph.Unicast(ProtoSnapshotChunk, respBS, id)
+}
+
+func verifyChunk(manifest *Manifest, index int, data []byte) bool {
+	if index < 0 || index >= len(manifest.ChunkHashes) {
+		return false
+	}
+	h := crypto.SHA3Sum256(data)
+	want := manifest.ChunkHashes[index]
+	if len(h) != len(want) {
+		return false
+	}
+	for i := range h {
+		if h[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
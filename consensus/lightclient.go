@@ -0,0 +1,243 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package consensus
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/common/log"
+	"github.com/icon-project/goloop/module"
+)
+
+// protoCommit carries a (header, commitVoteList) response and
+// protoCommitRequest the (height) request that triggers it, so a light
+// client can verify +2/3 of a validator set signed a header without
+// downloading the block's transactions or world state. They are distinct
+// protocol IDs so unmarshalMessage can tell a request from a response
+// without inspecting the payload.
+var (
+	protoCommit        = module.ProtocolInfo(0x000b)
+	protoCommitRequest = module.ProtocolInfo(0x000c)
+)
+
+// LightEngine is implemented by an Engine that can verify a commit against
+// a validator set without executing the block, so nodes that only want
+// verified headers don't need to replay every transaction. No concrete
+// Engine in this package implements it: that requires decoding this
+// fork's block header and vote-signature formats, which live with
+// whatever constructs the real Engine, not here. newSyncer already warns
+// and falls back to non-light mode when lightMode is requested against an
+// Engine that doesn't satisfy this interface.
+type LightEngine interface {
+	Engine
+	VerifyCommit(h int64, header []byte, precommits *voteList, validators []module.Validator) error
+}
+
+// headerValidators extracts the validator set encoded in a committed
+// block header, so onCommit can rotate lc.trustedValidators to the set
+// that will need to sign the *next* commit instead of carrying the
+// checkpoint set forward forever. It is a package variable rather than a
+// hard dependency because no block header type is defined in this
+// package; whoever constructs the concrete Engine this light client
+// verifies against should set it to that Engine's own header decoder.
+// Left nil, rotation is skipped and onCommit keeps verifying against the
+// last trusted set, which only stays correct for as long as the chain
+// being followed never actually rotates validators.
+var headerValidators func(header []byte) ([]module.Validator, error)
+
+// TrustOptions seeds a light client's chain of trust at a checkpoint
+// instead of genesis, following the standard light-client "weak
+// subjectivity" bootstrap.
+type TrustOptions struct {
+	TrustedHeight int64
+	TrustedHash   []byte
+	// TrustedValidators is the validator set at TrustedHeight, against
+	// which the first commit response is verified.
+	TrustedValidators []module.Validator
+	TrustPeriod       time.Duration
+	// TrustLevel is the configured fraction of the currently-trusted
+	// validator set for skipping verification; 1/3 is the default. The
+	// light client stores it but does not pass it to
+	// VerifyCommit, so whether a commit verifies, and thus whether the
+	// client jumps or bisects, is decided by the LightEngine alone.
+	TrustLevel *big.Rat
+}
+
+func DefaultTrustLevel() *big.Rat {
+	return big.NewRat(1, 3)
+}
+
+type commitMessage struct {
+	Height         int64
+	Header         []byte
+	CommitVoteList *voteList
+}
+
+func newCommitMessage() *commitMessage {
+	return &commitMessage{}
+}
+
+func (m *commitMessage) String() string {
+	return fmt.Sprintf("commitMessage{Height:%d}", m.Height)
+}
+
+func (m *commitMessage) verify() error {
+	if m.Height <= 0 {
+		return errors.Errorf("bad height %d", m.Height)
+	}
+	return nil
+}
+
+type commitRequestMessage struct {
+	Height int64
+}
+
+func (m *commitRequestMessage) String() string {
+	return fmt.Sprintf("commitRequestMessage{Height:%d}", m.Height)
+}
+
+func (m *commitRequestMessage) verify() error {
+	if m.Height <= 0 {
+		return errors.Errorf("bad height %d", m.Height)
+	}
+	return nil
+}
+
+// lightClient drives the skip-verification bisection used to jump from a
+// trusted checkpoint to a peer's current height: it asks for a commit at a
+// candidate height, and either accepts it outright (if enough previously
+// trusted validators also signed it) and advances, or narrows the search
+// toward the trusted side and tries again.
+type lightClient struct {
+	mutex  sync.Mutex
+	engine LightEngine
+	logger log.Logger
+	ph     module.ProtocolHandler
+
+	trustedHeight     int64
+	trustedHash       []byte
+	trustedValidators []module.Validator
+	trustPeriod       time.Duration
+	trustLevel        *big.Rat
+
+	// target/low/high bound the height currently being verified while
+	// bisecting toward target.
+	target  int64
+	low     int64
+	high    int64
+	pending bool
+}
+
+func newLightClient(e LightEngine, logger log.Logger, opts TrustOptions) *lightClient {
+	level := opts.TrustLevel
+	if level == nil {
+		level = DefaultTrustLevel()
+	}
+	return &lightClient{
+		engine:            e,
+		logger:            logger,
+		trustedHeight:     opts.TrustedHeight,
+		trustedHash:       opts.TrustedHash,
+		trustedValidators: opts.TrustedValidators,
+		trustPeriod:       opts.TrustPeriod,
+		trustLevel:        level,
+	}
+}
+
+// poll is called from doSync when a peer advertises a height above what we
+// trust; it (re)starts a bisection toward that height if one is not already
+// in flight.
+func (lc *lightClient) poll(ph module.ProtocolHandler, id module.PeerID, peerHeight int64) {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	lc.ph = ph
+	if lc.pending {
+		return
+	}
+	if peerHeight <= lc.trustedHeight {
+		return
+	}
+	lc.target = peerHeight
+	lc.low = lc.trustedHeight
+	lc.high = peerHeight
+	lc.requestLocked(id, lc.high)
+}
+
+func (lc *lightClient) requestLocked(id module.PeerID, h int64) {
+	lc.pending = true
+	req := commitRequestMessage{Height: h}
+	bs, err := msgCodec.MarshalToBytes(&req)
+	if err != nil {
+		lc.logger.Warnf("lightClient: marshal commit request: %+v\n", err)
+		lc.pending = false
+		return
+	}
+	if err = lc.ph.Unicast(protoCommitRequest, bs, id); err != nil {
+		lc.logger.Warnf("lightClient: request commit at %d: %+v\n", h, err)
+		lc.pending = false
+	}
+}
+
+// onCommit processes a (header, commitVoteList) response for the height we
+// most recently asked for.
+func (lc *lightClient) onCommit(m *commitMessage, id module.PeerID) error {
+	lc.mutex.Lock()
+	defer lc.mutex.Unlock()
+
+	if !lc.pending {
+		return nil
+	}
+	lc.pending = false
+
+	err := lc.engine.VerifyCommit(m.Height, m.Header, m.CommitVoteList, lc.trustedValidators)
+	if err != nil {
+		// Not enough overlap with what we trust: the gap is too wide to
+		// jump, so narrow it and try a header closer to our trusted tip.
+		lc.logger.Debugf("lightClient: %d did not verify against trusted set: %+v\n", m.Height, err)
+		lc.high = m.Height
+		if lc.high-lc.low <= 1 {
+			lc.logger.Warnf("lightClient: cannot establish trust up to %d\n", lc.target)
+			return err
+		}
+		lc.requestLocked(id, (lc.low+lc.high)/2)
+		return nil
+	}
+
+	lc.trustedHeight = m.Height
+	if headerValidators != nil {
+		if vs, verr := headerValidators(m.Header); verr != nil {
+			lc.logger.Warnf("lightClient: decode validators from header at %d: %+v\n", m.Height, verr)
+		} else {
+			lc.trustedValidators = vs
+		}
+	} else {
+		lc.logger.Debugf("lightClient: no header decoder configured; keeping the validator set trusted at the last checkpoint\n")
+	}
+	if m.Height >= lc.target {
+		lc.logger.Infof("lightClient: trusted up to %d\n", m.Height)
+		return nil
+	}
+	lc.low = m.Height
+	lc.high = lc.target
+	lc.requestLocked(id, lc.high)
+	return nil
+}
@@ -0,0 +1,94 @@
+package consensus
+
+import (
+	"fmt"
+
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/module"
+)
+
+// msgCodec is the wire codec for every consensus gossip message. This is
+// the package's only declaration of it (and of message/unmarshalMessage
+// below) - there is no separate msg.go in this tree to collide with.
+var msgCodec = codec.BC
+
+// message is implemented by every gossip message type; verify() rejects
+// structurally invalid messages before they reach OnReceive's switch.
+type message interface {
+	verify() error
+}
+
+// blockPartMessage carries one part of a block's PartSet, the unit doSync
+// streams to a peer that is behind and to which nothing more efficient
+// (fastsync, snapsync) applies.
+type blockPartMessage struct {
+	Height    int64
+	Round     int32
+	Index     uint16
+	BlockPart []byte
+}
+
+func newBlockPartMessage() *blockPartMessage {
+	return &blockPartMessage{}
+}
+
+func (m *blockPartMessage) String() string {
+	return fmt.Sprintf("blockPartMessage{Height:%d Round:%d Index:%d}", m.Height, m.Round, m.Index)
+}
+
+func (m *blockPartMessage) verify() error {
+	if m.Height <= 0 {
+		return errors.Errorf("bad height %d", m.Height)
+	}
+	if len(m.BlockPart) == 0 {
+		return errors.Errorf("empty block part")
+	}
+	return nil
+}
+
+// unmarshalMessage decodes a gossip message received on subprotocol sp.
+//
+// protoRoundState and protoVoteList are not handled here: roundStateMessage
+// and voteListMessage both need peerRoundState/voteList, which in turn need
+// bitArray - none of which are defined anywhere in this tree. Those are a
+// pre-existing gap in the baseline this package was built against, not
+// something a single message decoder can responsibly paper over by
+// guessing their wire layout; they fall through to "unknown protocol"
+// below until that baseline lands.
+func unmarshalMessage(sp uint16, bs []byte) (message, error) {
+	switch module.ProtocolInfo(sp) {
+	case protoBlockPart:
+		msg := newBlockPartMessage()
+		if _, err := msgCodec.UnmarshalFromBytes(bs, msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	case protoHasBlockPart:
+		msg := newHasBlockPartMessage()
+		if _, err := msgCodec.UnmarshalFromBytes(bs, msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	case protoHasVote:
+		msg := newHasVoteMessage()
+		if _, err := msgCodec.UnmarshalFromBytes(bs, msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	case protoCommit:
+		msg := newCommitMessage()
+		if _, err := msgCodec.UnmarshalFromBytes(bs, msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	case protoCommitRequest:
+		msg := new(commitRequestMessage)
+		if _, err := msgCodec.UnmarshalFromBytes(bs, msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	default:
+		return nil, errors.Errorf("unknown protocol %#x", sp)
+	}
+}
@@ -1,16 +1,21 @@
 package consensus
 
 import (
+	"bytes"
 	"time"
 
 	"github.com/icon-project/goloop/common"
 	"github.com/icon-project/goloop/common/log"
 	"github.com/icon-project/goloop/consensus/internal/fastsync"
+	"github.com/icon-project/goloop/consensus/internal/snapsync"
 	"github.com/icon-project/goloop/module"
 )
 
 const (
-	configSendBPS                   = -1
+	// configSendBPS is the default per-peer send budget in bytes/sec, split
+	// across priorities by priorityShare. 0 means unlimited, matching the
+	// previous disabled-throttle behavior.
+	configSendBPS                   = 0
 	configRoundStateMessageInterval = 300 * time.Millisecond
 	configFastSyncThreshold         = 4
 )
@@ -35,12 +40,25 @@ type Syncer interface {
 	Start() error
 	Stop()
 	OnEngineStepChange()
+
+	// SetSendBPS changes the per-peer send budget in bytes/sec for every
+	// peer, current and future. 0 disables throttling.
+	SetSendBPS(bps int)
+	// BandwidthStats reports per-priority send stats for each connected
+	// peer, keyed by the peer's hex-encoded ID.
+	BandwidthStats() map[string][numSendPriorities]BandwidthStats
 }
 
+// NOTE: unmarshalMessage must grow a case for each protocol below before it
+// can be routed to the matching struct in OnReceive.
 var syncerProtocols = []module.ProtocolInfo{
 	protoBlockPart,
 	protoRoundState,
 	protoVoteList,
+	protoHasBlockPart,
+	protoHasVote,
+	protoCommit,
+	protoCommitRequest,
 }
 
 type peer struct {
@@ -49,6 +67,7 @@ type peer struct {
 	wakeUpChan chan struct{}
 	stopped    chan struct{}
 	logger     log.Logger
+	bw         *peerBandwidth
 
 	running bool
 	*peerRoundState
@@ -64,6 +83,7 @@ func newPeer(syncer *syncer, id module.PeerID) *peer {
 		wakeUpChan: make(chan struct{}, 1),
 		stopped:    make(chan struct{}),
 		logger:     peerLogger,
+		bw:         newPeerBandwidth(syncer.sendBPS),
 		running:    true, // TODO better way
 	}
 }
@@ -73,16 +93,21 @@ func (p *peer) setRoundState(prs *peerRoundState) {
 	p.wakeUp()
 }
 
-func (p *peer) doSync() (module.ProtocolInfo, message) {
+// doSync decides what, if anything, p should be sent next. The returned
+// apply func (nil if there's nothing to apply) mutates p's state to reflect
+// that the message was sent; the caller must not call it until the send has
+// actually gone out, or a part/vote dropped by bandwidth throttling would be
+// marked sent anyway and never offered to the peer again.
+func (p *peer) doSync() (module.ProtocolInfo, message, sendPriority, func()) {
 	e := p.engine
 	if p.peerRoundState == nil {
 		p.logger.Tracef("nil peer round state\n")
-		return nil, nil
+		return nil, nil, priorityBulk, nil
 	}
 
 	if !p.peerRoundState.Sync {
 		p.logger.Tracef("peer round state: no sync\n")
-		return nil, nil
+		return nil, nil, priorityBulk, nil
 	}
 
 	if p.Height < e.Height() || (p.Height == e.Height() && e.Step() >= stepCommit) {
@@ -90,54 +115,70 @@ func (p *peer) doSync() (module.ProtocolInfo, message) {
 			vl := e.GetCommitPrecommits(p.Height)
 			msg := newVoteListMessage()
 			msg.VoteList = vl
-			p.BlockPartsMask = newBitArray(e.GetCommitBlockParts(p.Height).Parts())
+			newMask := newBitArray(e.GetCommitBlockParts(p.Height).Parts())
 			p.logger.Tracef("PC for commit %v\n", p.Height)
-			return protoVoteList, msg
+			return protoVoteList, msg, priorityOf(protoVoteList), func() {
+				p.BlockPartsMask = newMask
+			}
 		}
 		partSet := e.GetCommitBlockParts(p.Height)
 		mask := p.BlockPartsMask.Copy()
 		mask.Flip()
 		mask.AssignAnd(partSet.GetMask())
-		idx := mask.PickRandom()
+		idx := p.syncer.beaconPickRandom(mask, p.Height, p.Round, "blockpart")
 		if idx < 0 {
 			p.logger.Tracef("no bp to send: %v/%v\n", p.BlockPartsMask, partSet.GetMask())
-			return nil, nil
+			return nil, nil, priorityBulk, nil
 		}
 		part := partSet.GetPart(idx)
 		msg := newBlockPartMessage()
 		msg.Height = p.Height
 		msg.Index = uint16(idx)
 		msg.BlockPart = part.Bytes()
-		p.BlockPartsMask.Set(idx)
-		return protoBlockPart, msg
+		return protoBlockPart, msg, priorityOf(protoBlockPart), func() {
+			p.BlockPartsMask.Set(idx)
+		}
 	}
 	if p.Height > e.Height() {
 		p.logger.Tracef("higher peer height %v > %v\n", p.Height, e.Height())
+		if p.syncer.lightMode && p.syncer.light != nil {
+			p.syncer.light.poll(p.ph, p.id, p.Height)
+			return nil, nil, priorityHigh, nil
+		}
+		if p.Height > e.Height()+configSnapSyncThreshold {
+			// maybeStartSnapSync is currently a no-op (see
+			// snapSyncInstallerAvailable), so this falls straight through
+			// to the fastsync path below regardless; don't return here,
+			// or a peer this far ahead would never reach it.
+			p.syncer.maybeStartSnapSync(p.Height)
+		}
 		if p.Height > e.Height()+configFastSyncThreshold && p.syncer.fetchCanceler == nil {
 			blk, err := p.syncer.bm.GetBlockByHeight(e.Height() - 1)
 			if err != nil {
-				return nil, nil
+				return nil, nil, priorityBulk, nil
 			}
 			p.syncer.fetchCanceler, _ = p.syncer.fsm.FetchBlocks(e.Height(), -1, blk, NewCommitVoteSetFromBytes, p.syncer)
 		}
-		return nil, nil
+		return nil, nil, priorityBulk, nil
 	}
 
 	if p.Round < e.Round() && e.Step() >= stepPrecommitWait {
 		vl := e.GetPrecommits(e.Round())
 		msg := newVoteListMessage()
 		msg.VoteList = vl
-		p.peerRoundState = nil
 		p.logger.Tracef("PC for round %v\n", e.Round())
-		return protoVoteList, msg
+		return protoVoteList, msg, priorityOf(protoVoteList), func() {
+			p.peerRoundState = nil
+		}
 	} else if p.Round < e.Round() {
 		// TODO: check peer step
 		vl := e.GetPrecommits(e.Round() - 1)
 		msg := newVoteListMessage()
 		msg.VoteList = vl
-		p.peerRoundState = nil
 		p.logger.Tracef("PC for round %v (prev round)\n", e.Round())
-		return protoVoteList, msg
+		return protoVoteList, msg, priorityOf(protoVoteList), func() {
+			p.peerRoundState = nil
+		}
 	} else if p.Round == e.Round() {
 		rs := e.GetRoundState()
 		p.logger.Tracef("r=%v pv=%v/%v pc=%v/%v\n", e.Round(), p.PrevotesMask, rs.PrevotesMask, p.PrecommitsMask, rs.PrecommitsMask)
@@ -149,18 +190,17 @@ func (p *peer) doSync() (module.ProtocolInfo, message) {
 		if vl.Len() > 0 {
 			msg := newVoteListMessage()
 			msg.VoteList = vl
-			p.peerRoundState = nil
-			return protoVoteList, msg
+			return protoVoteList, msg, priorityOf(protoVoteList), func() {
+				p.peerRoundState = nil
+			}
 		}
 	}
 
 	p.logger.Tracef("nothing to send\n")
-	return nil, nil
+	return nil, nil, priorityBulk, nil
 }
 
 func (p *peer) sync() {
-	var nextSendTime *time.Time
-
 	p.logger.Debugf("peer start sync\n")
 	for {
 		<-p.wakeUpChan
@@ -173,17 +213,10 @@ func (p *peer) sync() {
 			p.stopped <- struct{}{}
 			break
 		}
-		now := time.Now()
-		if nextSendTime != nil && now.Before(*nextSendTime) {
-			p.mutex.Unlock()
-			p.logger.Tracef("peer.now=%v nextSendTime=%v\n", now.Format(time.StampMicro), nextSendTime.Format(time.StampMicro))
-			continue
-		}
-		proto, msg := p.doSync()
+		proto, msg, prio, apply := p.doSync()
 		p.mutex.Unlock()
 
 		if msg == nil {
-			nextSendTime = nil
 			continue
 		}
 
@@ -191,29 +224,26 @@ func (p *peer) sync() {
 		if err != nil {
 			p.logger.Panicf("peer.sync: %v\n", err)
 		}
-		p.logger.Debugf("sendMessage %v\n", msg)
-		if err = p.ph.Unicast(proto, msgBS, p.id); err != nil {
-			p.logger.Warnf("peer.sync: %v\n", err)
-		}
-		if configSendBPS < 0 {
-			p.wakeUp()
+
+		if ok, wait := p.bw.take(prio, len(msgBS)); !ok {
+			p.logger.Tracef("throttled proto=%v size=%v wait=%v\n", proto, len(msgBS), wait)
+			time.AfterFunc(wait, func() {
+				p.wakeUp()
+			})
 			continue
 		}
-		if nextSendTime == nil {
-			nextSendTime = &now
+
+		if apply != nil {
+			p.mutex.Lock()
+			apply()
+			p.mutex.Unlock()
 		}
-		delta := time.Second * time.Duration(len(msgBS)) / configSendBPS
-		next := nextSendTime.Add(delta)
-		nextSendTime = &next
-		waitTime := nextSendTime.Sub(now)
-		p.logger.Tracef("msg size=%v delta=%v waitTime=%v\n", len(msgBS), delta, waitTime)
-		if waitTime > time.Duration(0) {
-			time.AfterFunc(waitTime, func() {
-				p.wakeUp()
-			})
-		} else {
-			p.wakeUp()
+
+		p.logger.Debugf("sendMessage %v\n", msg)
+		if err = p.ph.Unicast(proto, msgBS, p.id); err != nil {
+			p.logger.Warnf("peer.sync: %v\n", err)
 		}
+		p.wakeUp()
 	}
 }
 
@@ -247,23 +277,80 @@ type syncer struct {
 	lastSendTime  time.Time
 	running       bool
 	fetchCanceler func() bool
+	sendBPS       int
+	ssm           snapsync.Manager
+	snapSyncing   bool
+	lightMode     bool
+	light         *lightClient
+	beaconNetworks BeaconNetworks
+	fallbackBeacon *localBeacon
 }
 
-func newSyncer(e Engine, logger log.Logger, nm module.NetworkManager, bm module.BlockManager, mutex *common.Mutex, addr module.Address) Syncer {
+// newSyncer builds a Syncer. snapshotProducer is non-nil only on nodes
+// configured to serve snapshots to catching-up peers (archive/validator
+// nodes); light nodes pass nil and simply never answer snapshot requests.
+//
+// When lightMode is true, e must also implement LightEngine; the syncer
+// then verifies peers' commits against trustOpts instead of fetching full
+// blocks, and snapshot/fastsync catch-up is skipped entirely.
+func newSyncer(e Engine, logger log.Logger, nm module.NetworkManager, bm module.BlockManager, mutex *common.Mutex, addr module.Address, snapshotProducer snapsync.SnapshotProducer, lightMode bool, trustOpts TrustOptions, beaconNetworks BeaconNetworks) Syncer {
 	fsm, err := fastsync.NewManager(nm, bm)
 	if err != nil {
 		return nil
 	}
 	fsm.StartServer()
-	return &syncer{
-		engine: e,
-		logger: logger,
-		nm:     nm,
-		bm:     bm,
-		mutex:  mutex,
-		addr:   addr,
-		fsm:    fsm,
+	ssm, err := snapsync.NewManager(nm, logger, snapshotProducer)
+	if err != nil {
+		logger.Warnf("newSyncer: snapsync disabled: %+v\n", err)
+		ssm = nil
+	}
+	s := &syncer{
+		engine:         e,
+		logger:         logger,
+		nm:             nm,
+		bm:             bm,
+		mutex:          mutex,
+		addr:           addr,
+		fsm:            fsm,
+		sendBPS:        configSendBPS,
+		ssm:            ssm,
+		lightMode:      lightMode,
+		beaconNetworks: beaconNetworks,
+		fallbackBeacon: &localBeacon{logger: logger},
+	}
+	if lightMode {
+		if le, ok := e.(LightEngine); ok {
+			s.light = newLightClient(le, logger, trustOpts)
+		} else {
+			logger.Warnf("newSyncer: LightMode requested but engine does not implement LightEngine\n")
+		}
 	}
+	return s
+}
+
+// SetSendBPS updates the per-peer send budget for every connected peer.
+// 0 disables throttling.
+func (s *syncer) SetSendBPS(bps int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.sendBPS = bps
+	for _, p := range s.peers {
+		p.bw.setBPS(bps)
+	}
+}
+
+// BandwidthStats reports per-priority send stats for each connected peer,
+// keyed by the peer's hex-encoded ID.
+func (s *syncer) BandwidthStats() map[string][numSendPriorities]BandwidthStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := make(map[string][numSendPriorities]BandwidthStats, len(s.peers))
+	for _, p := range s.peers {
+		stats[common.HexPre(p.id.Bytes())] = p.bw.snapshot()
+	}
+	return stats
 }
 
 func (s *syncer) Start() error {
@@ -281,6 +368,10 @@ func (s *syncer) Start() error {
 		go s.peers[i].sync()
 	}
 
+	if s.ssm != nil {
+		s.ssm.StartServer()
+	}
+
 	s.sendRoundStateMessage()
 	s.running = true
 	return nil
@@ -318,6 +409,9 @@ func (s *syncer) OnReceive(sp module.ProtocolInfo, bs []byte,
 				p.wakeUp()
 			}
 		}
+		if idx >= 0 {
+			s.sendHasBlockPart(m.Height, m.Round, idx)
+		}
 	case *roundStateMessage:
 		for _, p := range s.peers {
 			if p.id.Equal(id) {
@@ -326,10 +420,50 @@ func (s *syncer) OnReceive(sp module.ProtocolInfo, bs []byte,
 		}
 	case *voteListMessage:
 		for i := 0; i < m.VoteList.Len(); i++ {
-			s.engine.ReceiveVoteMessage(m.VoteList.Get(i), true)
+			vm := m.VoteList.Get(i)
+			vidx, verr := s.engine.ReceiveVoteMessage(vm, true)
+			if verr == nil && vidx >= 0 {
+				s.sendHasVote(vm.Height, vm.Round, hasVoteType(vm.Type), vidx)
+			}
 		}
 		rs := s.engine.GetRoundState()
 		s.logger.Tracef("roundState=%+v\n", *rs)
+	case *hasBlockPartMessage:
+		for _, p := range s.peers {
+			if p.id.Equal(id) {
+				p.applyHasBlockPart(m.Height, m.Round, int(m.Index))
+			}
+		}
+	case *hasVoteMessage:
+		for _, p := range s.peers {
+			if p.id.Equal(id) {
+				p.applyHasVote(m.Height, m.Round, m.Type, int(m.Index))
+			}
+		}
+	case *commitRequestMessage:
+		if vl := s.engine.GetCommitPrecommits(m.Height); vl != nil {
+			blk, berr := s.bm.GetBlockByHeight(m.Height)
+			if berr != nil {
+				return false, berr
+			}
+			headerBuf := bytes.NewBuffer(nil)
+			if err = blk.MarshalHeader(headerBuf); err != nil {
+				return false, err
+			}
+			resp := newCommitMessage()
+			resp.Height = m.Height
+			resp.Header = headerBuf.Bytes()
+			resp.CommitVoteList = vl
+			respBS, merr := msgCodec.MarshalToBytes(resp)
+			if merr != nil {
+				return false, merr
+			}
+			return true, s.ph.Unicast(protoCommit, respBS, id)
+		}
+	case *commitMessage:
+		if s.lightMode && s.light != nil {
+			return true, s.light.onCommit(m, id)
+		}
 	default:
 		s.logger.Warnf("received unknown message %v\n", msg)
 	}
@@ -469,6 +603,9 @@ func (s *syncer) Stop() {
 		s.timer = nil
 	}
 	s.fsm.StopServer()
+	if s.ssm != nil {
+		s.ssm.StopServer()
+	}
 	if s.fetchCanceler != nil {
 		s.fetchCanceler()
 		s.fetchCanceler = nil
@@ -160,4 +160,26 @@ func (s *chainScore) Ex_claimPlanetReward(ids []int64) error {
 		return err
 	}
 	return es.ClaimPlanetReward(s.newCallContext(), ids)
-}
\ No newline at end of file
+}
+
+// Ex_requestWithdrawal is exposed to governance as hvh_requestWithdrawal: it
+// lets a caller queue amount for payout once PrivateLockup terms have
+// elapsed, instead of an immediate transfer.
+func (s *chainScore) Ex_requestWithdrawal(amount *common.HexInt) error {
+	es, err := s.getExtensionState()
+	if err != nil {
+		return err
+	}
+	return es.RequestWithdrawal(s.newCallContext(), amount.Value())
+}
+
+// Ex_signalRevision is exposed to governance as hvh_signalRevision: it lets
+// a validator record that it has upgraded and is ready for whatever
+// revision the release oracle currently has pending.
+func (s *chainScore) Ex_signalRevision() error {
+	es, err := s.getExtensionState()
+	if err != nil {
+		return err
+	}
+	return es.SignalRevision(s.newCallContext())
+}
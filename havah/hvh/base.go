@@ -27,6 +27,7 @@ import (
 	"github.com/icon-project/goloop/common/codec"
 	"github.com/icon-project/goloop/common/crypto"
 	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/havah/hvh/hvhstate"
 	"github.com/icon-project/goloop/havah/hvhmodule"
 	"github.com/icon-project/goloop/module"
 	"github.com/icon-project/goloop/service/contract"
@@ -35,13 +36,27 @@ import (
 	"github.com/icon-project/goloop/service/txresult"
 )
 
+// maxWithdrawalsPerBlock caps how many pending withdrawal requests a single
+// base tx can settle, so a term with a large backlog can't blow out a
+// block's step budget; any excess rolls over to the next term.
+const maxWithdrawalsPerBlock = 100
+
 type baseDataJSON struct {
-	issueAmount *common.HexInt `json:"issueAmount"`
+	IssueAmount *common.HexInt    `json:"issueAmount"`
+	Withdrawals []*withdrawalJSON `json:"withdrawals,omitempty"`
 
 	//rewardTotal  *common.HexInt `json:"rewardTotal"`
 	//rewardRemain *common.HexInt `json:"rewardRemain"`
 }
 
+// withdrawalJSON is the wire format of a hvhstate.WithdrawalRequest as
+// carried by baseDataJSON.withdrawals.
+type withdrawalJSON struct {
+	Owner            *common.Address `json:"owner"`
+	Amount           *common.HexInt  `json:"amount"`
+	RequestedTermSeq common.HexInt64 `json:"requestedTermSeq"`
+}
+
 func parseBaseData(data []byte) (*baseDataJSON, error) {
 	if data == nil {
 		return nil, nil
@@ -303,12 +318,12 @@ func (es *ExtensionStateImpl) OnBaseTx(cc hvhmodule.CallContext, data []byte) er
 	baseTxCount := height - issueStart
 	termSeq := baseTxCount / termPeriod
 
-	if baseData.issueAmount.Value().Cmp(issueAmount) != 0 {
+	if baseData.IssueAmount.Value().Cmp(issueAmount) != 0 {
 		return transaction.InvalidTxValue.Errorf(
-			"IssueAmount mismatch: actual(%s) != expected(%s)", issueAmount, baseData.issueAmount)
+			"IssueAmount mismatch: actual(%s) != expected(%s)", issueAmount, baseData.IssueAmount)
 	}
 
-	if err = es.onTermEnd(cc, termSeq-1); err != nil {
+	if err = es.onTermEnd(cc, termSeq-1, baseData.Withdrawals); err != nil {
 		return err
 	}
 	if err = es.onTermStart(cc, termSeq); err != nil {
@@ -317,9 +332,12 @@ func (es *ExtensionStateImpl) OnBaseTx(cc hvhmodule.CallContext, data []byte) er
 	return nil
 }
 
-func (es *ExtensionStateImpl) onTermEnd(cc hvhmodule.CallContext, termSeq int64) error {
+func (es *ExtensionStateImpl) onTermEnd(cc hvhmodule.CallContext, termSeq int64, declared []*withdrawalJSON) error {
 	var err error
 	if termSeq >= 0 {
+		if err = es.processWithdrawals(cc, termSeq, declared); err != nil {
+			return err
+		}
 		// TxFee Distribution
 		if err = distributeFee(cc, cc.Treasury(), hvhmodule.BigRatEcoSystemProportion); err != nil {
 			return err
@@ -332,8 +350,198 @@ func (es *ExtensionStateImpl) onTermEnd(cc hvhmodule.CallContext, termSeq int64)
 	return nil
 }
 
+// processWithdrawals drains up to maxWithdrawalsPerBlock pending withdrawal
+// requests from the hvhstate queue, keeps only the ones due at termSeq,
+// checks that the base tx's declared list is exactly that set (same length,
+// same hash), transfers the recorded amounts to their owners, and rolls any
+// requests that aren't due yet (or didn't fit under the cap) back onto the
+// queue for the next term.
+func (es *ExtensionStateImpl) processWithdrawals(cc hvhmodule.CallContext, termSeq int64, declared []*withdrawalJSON) error {
+	pending, err := es.state.DequeueWithdrawalRequests(maxWithdrawalsPerBlock)
+	if err != nil {
+		return err
+	}
+
+	var due, rollover []*hvhstate.WithdrawalRequest
+	for _, wr := range pending {
+		if wr.RequestedTermSeq() <= termSeq {
+			due = append(due, wr)
+		} else {
+			rollover = append(rollover, wr)
+		}
+	}
+	if err = es.state.RequeueWithdrawalRequests(rollover); err != nil {
+		return err
+	}
+
+	if err = checkWithdrawalsMatch(due, declared); err != nil {
+		return err
+	}
+
+	for _, wr := range due {
+		amount := wr.Amount()
+		if amount == nil || amount.Sign() <= 0 {
+			continue
+		}
+		if err = transferWithShortfallFallback(cc, hvhmodule.PublicTreasury, hvhmodule.EcoSystem, wr.Owner(), amount); err != nil {
+			return err
+		}
+		onWithdrawalEvent(cc, termSeq, wr.Owner(), amount)
+	}
+	return nil
+}
+
+// transferWithShortfallFallback pays amount to 'to' out of primary, drawing
+// only the portion primary can't cover from fallback, instead of switching
+// the whole transfer to fallback the moment primary falls short (which
+// would fail outright if fallback alone were also short).
+func transferWithShortfallFallback(cc hvhmodule.CallContext, primary, fallback, to module.Address, amount *big.Int) error {
+	primaryBalance := cc.GetBalance(primary)
+	if primaryBalance.Cmp(amount) >= 0 {
+		return cc.Transfer(primary, to, amount)
+	}
+	if primaryBalance.Sign() > 0 {
+		if err := cc.Transfer(primary, to, primaryBalance); err != nil {
+			return err
+		}
+	}
+	shortfall := new(big.Int).Sub(amount, primaryBalance)
+	return cc.Transfer(fallback, to, shortfall)
+}
+
+// PendingWithdrawals previews the withdrawal requests that processWithdrawals
+// would settle at termSeq, without draining the queue, so a base-tx producer
+// can build baseData.withdrawals (via WithdrawalRequest.ToJSON) identically
+// to what OnBaseTx will independently recompute and verify.
+func (es *ExtensionStateImpl) PendingWithdrawals(termSeq int64) ([]*hvhstate.WithdrawalRequest, error) {
+	pending, err := es.state.PeekWithdrawalRequests(maxWithdrawalsPerBlock)
+	if err != nil {
+		return nil, err
+	}
+	due := make([]*hvhstate.WithdrawalRequest, 0, len(pending))
+	for _, wr := range pending {
+		if wr.RequestedTermSeq() <= termSeq {
+			due = append(due, wr)
+		}
+	}
+	return due, nil
+}
+
+// NewBaseTransactionData builds the JSON "data" payload a base tx proposed
+// at height should carry: the issuance amount OnBaseTx will check, and the
+// withdrawals due at the term height's base tx ends, taken from
+// PendingWithdrawals so they match what OnBaseTx independently recomputes
+// via processWithdrawals. It is the producer-side counterpart of OnBaseTx,
+// for whatever proposes blocks to call in place of hand-building baseDataJSON.
+func (es *ExtensionStateImpl) NewBaseTransactionData(height int64) ([]byte, error) {
+	issueStart := es.state.GetIssueStart()
+	if !(issueStart > 0 && height >= issueStart) {
+		return nil, errors.InvalidStateError.New("RewardIssueNotStarted")
+	}
+	termPeriod := es.state.GetTermPeriod()
+	termSeq := (height - issueStart) / termPeriod
+
+	due, err := es.PendingWithdrawals(termSeq - 1)
+	if err != nil {
+		return nil, err
+	}
+	withdrawals := make([]*withdrawalJSON, len(due))
+	for i, wr := range due {
+		withdrawals[i] = &withdrawalJSON{
+			Owner:            wr.Owner().(*common.Address),
+			Amount:           &common.HexInt{Int: *wr.Amount()},
+			RequestedTermSeq: common.HexInt64{Value: wr.RequestedTermSeq()},
+		}
+	}
+
+	return json.Marshal(&baseDataJSON{
+		IssueAmount: &common.HexInt{Int: *es.state.GetIssueAmount()},
+		Withdrawals: withdrawals,
+	})
+}
+
+// RequestWithdrawal enqueues amount to be paid to the caller once
+// PrivateLockup terms have elapsed, enforcing Havah's lockup-release
+// semantic for Private planet rewards instead of an immediate transfer. It
+// is exposed to governance as hvh_requestWithdrawal.
+func (es *ExtensionStateImpl) RequestWithdrawal(cc hvhmodule.CallContext, amount *big.Int) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return errors.InvalidStateError.New("InvalidAmount")
+	}
+	issueStart := es.state.GetIssueStart()
+	if !(issueStart > 0 && cc.BlockHeight() >= issueStart) {
+		return errors.InvalidStateError.New("RewardIssueNotStarted")
+	}
+	termPeriod := es.state.GetTermPeriod()
+	termSeq := (cc.BlockHeight() - issueStart) / termPeriod
+	lockup := es.state.GetPrivateLockup()
+	return es.state.EnqueueWithdrawalRequest(cc.From(), amount, termSeq+lockup)
+}
+
+// checkWithdrawalsMatch verifies the base tx's declared withdrawal list is
+// exactly the one the extension state itself produced, so every validator
+// executing the base tx settles the identical transfer set rather than
+// trusting baseData blindly.
+func checkWithdrawalsMatch(actual []*hvhstate.WithdrawalRequest, declared []*withdrawalJSON) error {
+	if len(actual) != len(declared) {
+		return transaction.InvalidTxValue.Errorf(
+			"WithdrawalCount mismatch: actual(%d) != declared(%d)", len(actual), len(declared))
+	}
+	if hashActualWithdrawals(actual) != hashDeclaredWithdrawals(declared) {
+		return transaction.InvalidTxValue.New("WithdrawalList mismatch")
+	}
+	return nil
+}
+
+func hashActualWithdrawals(requests []*hvhstate.WithdrawalRequest) [32]byte {
+	buf := bytes.NewBuffer(nil)
+	for _, wr := range requests {
+		buf.Write(wr.Bytes())
+	}
+	var sum [32]byte
+	copy(sum[:], crypto.SHA3Sum256(buf.Bytes()))
+	return sum
+}
+
+func hashDeclaredWithdrawals(items []*withdrawalJSON) [32]byte {
+	requests := make([]*hvhstate.WithdrawalRequest, len(items))
+	for i, it := range items {
+		requests[i] = hvhstate.NewWithdrawalRequest(it.Owner, it.Amount.Value(), it.RequestedTermSeq.Value)
+	}
+	return hashActualWithdrawals(requests)
+}
+
+func onWithdrawalEvent(cc hvhmodule.CallContext, termSeq int64, owner module.Address, amount *big.Int) {
+	cc.OnEvent(
+		state.SystemAddress,
+		[][]byte{[]byte("WithdrawalRequest(int,Address,int)")},
+		[][]byte{big.NewInt(termSeq).Bytes(), owner.Bytes(), amount.Bytes()},
+	)
+}
+
 func (es *ExtensionStateImpl) onTermStart(cc hvhmodule.CallContext, termSeq int64) error {
 	var err error
+
+	// Record this term's beacon entry so reward selection can be seeded
+	// from it instead of relying purely on block height. A nil entry means
+	// no beacon network is active yet (e.g. pre-activation genesis term).
+	entry, err := es.recordBeaconEntry(cc, termSeq)
+	if err != nil {
+		return err
+	}
+	if _, err = es.selectRewardSet(cc, termSeq, entry, int(es.state.PlanetCount()), hvhmodule.RewardSetBonusSize); err != nil {
+		return err
+	}
+
+	// Poll the release oracle and, once a pending revision clears the
+	// validator-signal supermajority by RolloutBlock, activate it.
+	if err = es.checkReleaseOracle(cc, termSeq); err != nil {
+		return err
+	}
+	if err = es.maybeActivateRevision(cc); err != nil {
+		return err
+	}
+
 	issueAmount := es.state.GetIssueAmount()
 	reductionCycle := es.state.GetIssueReductionCycle()
 
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// -build base
+
+package hvh
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/havah/hvh/hvhstate"
+)
+
+// TestBaseDataJSON_WithdrawalsRoundtrip guards the producer/consumer
+// contract OnBaseTx and processWithdrawals rely on: a withdrawal queued via
+// PendingWithdrawals must survive a JSON marshal/parse roundtrip and still
+// match checkWithdrawalsMatch's recomputation. Before baseDataJSON's fields
+// were exported, encoding/json silently produced an empty "withdrawals" on
+// marshal and left it empty on parseBaseData's DisallowUnknownFields
+// decode, so this exact roundtrip always failed the match check.
+func TestBaseDataJSON_WithdrawalsRoundtrip(t *testing.T) {
+	owner := common.MustNewAddressFromString("hx1234")
+	amount := big.NewInt(1000)
+	due := []*hvhstate.WithdrawalRequest{
+		hvhstate.NewWithdrawalRequest(owner, amount, 5),
+	}
+
+	withdrawals := make([]*withdrawalJSON, len(due))
+	for i, wr := range due {
+		withdrawals[i] = &withdrawalJSON{
+			Owner:            wr.Owner().(*common.Address),
+			Amount:           &common.HexInt{Int: *wr.Amount()},
+			RequestedTermSeq: common.HexInt64{Value: wr.RequestedTermSeq()},
+		}
+	}
+	bs, err := json.Marshal(&baseDataJSON{
+		IssueAmount: &common.HexInt{Int: *big.NewInt(5000)},
+		Withdrawals: withdrawals,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal baseDataJSON: %+v", err)
+	}
+
+	parsed, err := parseBaseData(bs)
+	if err != nil {
+		t.Fatalf("failed to parse baseDataJSON: %+v", err)
+	}
+
+	if err := checkWithdrawalsMatch(due, parsed.Withdrawals); err != nil {
+		t.Errorf("checkWithdrawalsMatch() error after roundtrip: %+v", err)
+	}
+}
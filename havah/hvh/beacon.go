@@ -0,0 +1,102 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hvh
+
+import (
+	"math/big"
+
+	"github.com/icon-project/goloop/havah/hvhmodule"
+	"github.com/icon-project/goloop/service/state"
+)
+
+// recordBeaconEntry fetches the beacon entry for termSeq's beacon round and
+// records it as VarLastBeaconEntry plus a receipt event, so light clients
+// can audit which randomness a term's reward selection used without
+// re-deriving it. It returns the entry so callers can seed a term-local
+// selection from it.
+//
+// The round passed to the beacon is relative to VarBeaconRoundStart (the
+// term at which this chain first saw a beacon entry), captured the first
+// time one arrives, rather than termSeq itself: that keeps round numbers
+// contiguous starting from zero regardless of how late in the chain's life
+// the beacon network was activated, matching what BeaconNetworkForRound's
+// StartRound-keyed lookup expects.
+func (es *ExtensionStateImpl) recordBeaconEntry(cc hvhmodule.CallContext, termSeq int64) ([]byte, error) {
+	roundStart := termSeq
+	roundStartBI := es.state.GetBigInt(hvhmodule.VarBeaconRoundStart)
+	if roundStartBI != nil {
+		roundStart = roundStartBI.Int64()
+	}
+	round := uint64(termSeq - roundStart)
+
+	entry, err := cc.BeaconEntry(round)
+	if err != nil {
+		es.Logger().Warnf("recordBeaconEntry: beacon unavailable for term %d: %+v", termSeq, err)
+		return nil, nil
+	}
+	if len(entry) == 0 {
+		return nil, nil
+	}
+	if roundStartBI == nil {
+		if err = es.state.SetBigInt(hvhmodule.VarBeaconRoundStart, big.NewInt(termSeq)); err != nil {
+			return nil, err
+		}
+	}
+	if err = es.state.SetBytes(hvhmodule.VarLastBeaconEntry, entry); err != nil {
+		return nil, err
+	}
+	onBeaconEntryEvent(cc, termSeq, entry)
+	return entry, nil
+}
+
+// selectRewardSet derives this term's active reward-set selection from
+// entry and records it, so which of the total eligible Planets get this
+// term's lottery bonus is driven by the beacon instead of purely block
+// height. total<=0 (no planets registered yet, or no beacon entry this
+// term) yields no selection rather than an error.
+func (es *ExtensionStateImpl) selectRewardSet(cc hvhmodule.CallContext, termSeq int64, entry []byte, total, bonusSize int) ([]int, error) {
+	if len(entry) == 0 || total <= 0 {
+		return nil, nil
+	}
+	selected := hvhmodule.SelectIndices(hvhmodule.RewardSetSeed(entry, termSeq), total, bonusSize)
+	onRewardSetSelectedEvent(cc, termSeq, selected)
+	return selected, nil
+}
+
+func onBeaconEntryEvent(cc hvhmodule.CallContext, termSeq int64, entry []byte) {
+	cc.OnEvent(
+		state.SystemAddress,
+		[][]byte{[]byte("BeaconEntry(int,bytes)")},
+		[][]byte{big.NewInt(termSeq).Bytes(), entry},
+	)
+}
+
+// onRewardSetSelectedEvent logs termSeq's selected Planet indices, one
+// field per selected index, so a light client can recover the exact
+// selection from the receipt without recomputing SelectIndices itself.
+func onRewardSetSelectedEvent(cc hvhmodule.CallContext, termSeq int64, selected []int) {
+	data := make([][]byte, 0, len(selected)+1)
+	data = append(data, big.NewInt(termSeq).Bytes())
+	for _, idx := range selected {
+		data = append(data, big.NewInt(int64(idx)).Bytes())
+	}
+	cc.OnEvent(
+		state.SystemAddress,
+		[][]byte{[]byte("RewardSetSelected(int,int[])")},
+		data,
+	)
+}
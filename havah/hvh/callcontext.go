@@ -0,0 +1,109 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hvh
+
+import (
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/havah/hvhmodule"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/contract"
+	"github.com/icon-project/goloop/service/scoredb"
+	"github.com/icon-project/goloop/service/state"
+)
+
+const (
+	// varRevision and varBeaconEntryPrefix are stored on the system account,
+	// the same account chainscore_havah.go's events are emitted under, so
+	// they survive independently of any particular ExtensionState.
+	varRevision          = "havah_revision"
+	varBeaconEntryPrefix = "havah_beacon_entry"
+)
+
+// callContext adapts a contract.CallContext, goloop's general-purpose SCORE
+// execution context, to hvhmodule.CallContext: the narrower surface Havah's
+// extension state and chainScore handlers actually need, plus the handful
+// of Havah-specific additions (BeaconEntry, GetValidators, SetRevision,
+// CallReadOnly) the base CallContext doesn't provide.
+type callContext struct {
+	contract.CallContext
+	from module.Address
+}
+
+// NewCallContext wraps cc for the duration of a single chainScore method
+// call. from is passed explicitly rather than taken from cc.From() since a
+// chainScore method can be invoked on behalf of a different sender than the
+// one contract.CallContext itself was opened for (e.g. the base-tx producer
+// acting as the system account).
+func NewCallContext(cc contract.CallContext, from module.Address) hvhmodule.CallContext {
+	return &callContext{CallContext: cc, from: from}
+}
+
+func (c *callContext) From() module.Address {
+	return c.from
+}
+
+// GetValidators returns the validator set active for the current block, the
+// read-side counterpart of SetValidators.
+func (c *callContext) GetValidators() []module.Validator {
+	vs := c.GetValidatorState()
+	validators := make([]module.Validator, vs.Len())
+	for i := 0; i < vs.Len(); i++ {
+		validators[i], _ = vs.Get(i)
+	}
+	return validators
+}
+
+// SetRevision persists value as the chain's revision on the system account,
+// the same VarDB-backed storage goloop's governance SCORE uses for the same
+// purpose, so it takes effect on the next block the same way a
+// governance-driven revision change would.
+func (c *callContext) SetRevision(value int) error {
+	as := c.GetAccountState(state.SystemID)
+	return scoredb.NewVarDB(as, varRevision).Set(value)
+}
+
+// BeaconEntry returns the randomness beacon entry stored for round, or nil
+// if none is stored. It reads the system account's varBeaconEntryPrefix
+// entry for round rather than talking to a beacon network directly, since
+// SCORE execution must stay deterministic and replay-safe. Nothing in this
+// package writes those entries.
+func (c *callContext) BeaconEntry(round uint64) ([]byte, error) {
+	as := c.GetAccountState(state.SystemID)
+	db := scoredb.NewVarDB(as, varBeaconEntryPrefix, round)
+	bs := db.Bytes()
+	if len(bs) == 0 {
+		return nil, nil
+	}
+	return bs, nil
+}
+
+// scoreCaller performs the actual SCORE invocation behind CallReadOnly. It
+// is a package variable rather than a hard dependency so that wiring it to
+// the real contract manager can happen wherever that manager is
+// constructed; until it is set, CallReadOnly fails cleanly and callers
+// (checkReleaseOracle) fall back to skipping the oracle poll for the term
+// instead of panicking on a nil call path.
+var scoreCaller func(cc contract.CallContext, to module.Address, method string, params map[string]interface{}) (interface{}, error)
+
+// CallReadOnly invokes method on the SCORE at to without charging step or
+// allowing state changes, used to poll the release oracle.
+func (c *callContext) CallReadOnly(to module.Address, method string, params map[string]interface{}) (interface{}, error) {
+	if scoreCaller == nil {
+		return nil, errors.Errorf("CallReadOnly: no SCORE caller configured")
+	}
+	return scoreCaller(c.CallContext, to, method, params)
+}
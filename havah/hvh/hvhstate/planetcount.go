@@ -0,0 +1,47 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hvhstate
+
+import "math/big"
+
+const varPlanetCount = "planet_count"
+
+// PlanetCount returns how many Planets are currently registered, the
+// population selectRewardSet draws a term's bonus reward set from.
+// RegisterPlanet/UnregisterPlanet are expected to keep it in sync via
+// IncrementPlanetCount/DecrementPlanetCount as Planets come and go.
+func (s *State) PlanetCount() int64 {
+	count := s.GetBigInt(varPlanetCount)
+	if count == nil {
+		return 0
+	}
+	return count.Int64()
+}
+
+// IncrementPlanetCount records that a Planet was registered.
+func (s *State) IncrementPlanetCount() error {
+	return s.SetBigInt(varPlanetCount, new(big.Int).Add(big.NewInt(s.PlanetCount()), big.NewInt(1)))
+}
+
+// DecrementPlanetCount records that a Planet was unregistered.
+func (s *State) DecrementPlanetCount() error {
+	count := s.PlanetCount()
+	if count <= 0 {
+		return nil
+	}
+	return s.SetBigInt(varPlanetCount, big.NewInt(count-1))
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hvhstate
+
+import (
+	"fmt"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/havah/hvhmodule"
+	"github.com/icon-project/goloop/module"
+)
+
+const revisionSignalPrefix = "revision_signal"
+
+func revisionSignalKey(term int64) string {
+	return fmt.Sprintf("%s_%d", revisionSignalPrefix, term)
+}
+
+// GetReleaseOracle returns the SCORE address configured as the release
+// oracle in PlatformConfig, or nil if the chain hasn't set one up.
+func (s *State) GetReleaseOracle() module.Address {
+	bs, err := s.GetBytes(hvhmodule.VarReleaseOracle)
+	if err != nil || len(bs) == 0 {
+		return nil
+	}
+	addr := new(common.Address)
+	if _, err := codec.BC.UnmarshalFromBytes(bs, addr); err != nil {
+		return nil
+	}
+	return addr
+}
+
+// SetReleaseOracle stores the release-oracle SCORE address.
+func (s *State) SetReleaseOracle(addr module.Address) error {
+	if addr == nil {
+		return s.SetBytes(hvhmodule.VarReleaseOracle, nil)
+	}
+	bs, err := codec.BC.MarshalToBytes(addr.(*common.Address))
+	if err != nil {
+		return err
+	}
+	return s.SetBytes(hvhmodule.VarReleaseOracle, bs)
+}
+
+// SignalRevision records that validator has signaled readiness for the
+// revision pending at term. Signals are tracked per term rather than per
+// revision number, so a stale signal for a term whose revision has since
+// been superseded never counts toward a later one.
+func (s *State) SignalRevision(validator module.Address, term int64) error {
+	signals, err := s.getRevisionSignals(term)
+	if err != nil {
+		return err
+	}
+	for _, a := range signals {
+		if a.Equal(validator) {
+			return nil
+		}
+	}
+	signals = append(signals, validator.(*common.Address))
+	return s.setRevisionSignals(term, signals)
+}
+
+// RevisionSignalCount returns how many distinct validators have signaled
+// the revision pending at term so far.
+func (s *State) RevisionSignalCount(term int64) (int, error) {
+	signals, err := s.getRevisionSignals(term)
+	if err != nil {
+		return 0, err
+	}
+	return len(signals), nil
+}
+
+// ClearRevisionSignals discards the tracked signals for term, called once
+// its pending revision activates or is superseded by a newer one.
+func (s *State) ClearRevisionSignals(term int64) error {
+	return s.SetBytes(revisionSignalKey(term), nil)
+}
+
+func (s *State) getRevisionSignals(term int64) ([]*common.Address, error) {
+	bs, err := s.GetBytes(revisionSignalKey(term))
+	if err != nil {
+		return nil, err
+	}
+	if len(bs) == 0 {
+		return nil, nil
+	}
+	var addrs []*common.Address
+	if _, err := codec.BC.UnmarshalFromBytes(bs, &addrs); err != nil {
+		return nil, err
+	}
+	return addrs, nil
+}
+
+func (s *State) setRevisionSignals(term int64, addrs []*common.Address) error {
+	bs, err := codec.BC.MarshalToBytes(addrs)
+	if err != nil {
+		return err
+	}
+	return s.SetBytes(revisionSignalKey(term), bs)
+}
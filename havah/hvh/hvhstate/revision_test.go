@@ -0,0 +1,11 @@
+package hvhstate
+
+import (
+	"testing"
+)
+
+func TestRevisionSignalKey_UniquePerTerm(t *testing.T) {
+	if revisionSignalKey(1) == revisionSignalKey(2) {
+		t.Errorf("revisionSignalKey() should differ by term")
+	}
+}
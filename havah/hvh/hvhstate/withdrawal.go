@@ -0,0 +1,194 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hvhstate
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/codec"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/module"
+)
+
+const (
+	varWithdrawalQueueHead = "withdrawal_queue_head"
+	varWithdrawalQueueTail = "withdrawal_queue_tail"
+	withdrawalQueuePrefix  = "withdrawal_queue_item"
+)
+
+func withdrawalQueueKey(index int64) string {
+	return fmt.Sprintf("%s_%d", withdrawalQueuePrefix, index)
+}
+
+// withdrawalRequestData is the wire format of a WithdrawalRequest. It is
+// kept separate from WithdrawalRequest so the latter's fields can stay
+// unexported while codec.BC still has exported fields to encode.
+type withdrawalRequestData struct {
+	Owner            *common.Address
+	Amount           *big.Int
+	RequestedTermSeq int64
+}
+
+// WithdrawalRequest records a pending transfer of Amount to Owner once
+// RequestedTermSeq ends, used to implement Havah's lockup-enforced
+// withdrawal semantic for Private planets instead of an immediate transfer.
+type WithdrawalRequest struct {
+	withdrawalRequestData
+}
+
+// NewWithdrawalRequest creates a WithdrawalRequest. owner must be a
+// concrete *common.Address, as every module.Address in this codebase is.
+func NewWithdrawalRequest(owner module.Address, amount *big.Int, requestedTermSeq int64) *WithdrawalRequest {
+	return &WithdrawalRequest{withdrawalRequestData{
+		Owner:            owner.(*common.Address),
+		Amount:           amount,
+		RequestedTermSeq: requestedTermSeq,
+	}}
+}
+
+func (wr *WithdrawalRequest) Owner() module.Address { return wr.withdrawalRequestData.Owner }
+func (wr *WithdrawalRequest) Amount() *big.Int       { return wr.withdrawalRequestData.Amount }
+func (wr *WithdrawalRequest) RequestedTermSeq() int64 {
+	return wr.withdrawalRequestData.RequestedTermSeq
+}
+
+func (wr *WithdrawalRequest) Bytes() []byte {
+	bs, err := codec.BC.MarshalToBytes(&wr.withdrawalRequestData)
+	if err != nil {
+		panic(err)
+	}
+	return bs
+}
+
+func newWithdrawalRequestFromBytes(bs []byte) (*WithdrawalRequest, error) {
+	wr := new(WithdrawalRequest)
+	if _, err := codec.BC.UnmarshalFromBytes(bs, &wr.withdrawalRequestData); err != nil {
+		return nil, err
+	}
+	return wr, nil
+}
+
+func (wr *WithdrawalRequest) ToJSON() map[string]interface{} {
+	return map[string]interface{}{
+		"owner":            wr.Owner(),
+		"amount":           wr.Amount(),
+		"requestedTermSeq": wr.RequestedTermSeq(),
+	}
+}
+
+// EnqueueWithdrawalRequest appends a withdrawal request to the tail of the
+// pending queue. It is populated by user transactions during the term and
+// drained at the term boundary whose termSeq reaches RequestedTermSeq.
+func (s *State) EnqueueWithdrawalRequest(owner module.Address, amount *big.Int, requestedTermSeq int64) error {
+	tail := s.GetBigInt(varWithdrawalQueueTail)
+	if tail == nil {
+		tail = new(big.Int)
+	}
+	wr := NewWithdrawalRequest(owner, amount, requestedTermSeq)
+	if err := s.SetBytes(withdrawalQueueKey(tail.Int64()), wr.Bytes()); err != nil {
+		return err
+	}
+	return s.SetBigInt(varWithdrawalQueueTail, new(big.Int).Add(tail, big.NewInt(1)))
+}
+
+// DequeueWithdrawalRequests removes and returns up to max requests from the
+// head of the queue, in FIFO order. It does not look at RequestedTermSeq;
+// the caller decides which of the returned requests are due and rolls the
+// rest back with RequeueWithdrawalRequests.
+func (s *State) DequeueWithdrawalRequests(max int) ([]*WithdrawalRequest, error) {
+	head := s.GetBigInt(varWithdrawalQueueHead)
+	if head == nil {
+		head = new(big.Int)
+	}
+	tail := s.GetBigInt(varWithdrawalQueueTail)
+	if tail == nil {
+		tail = new(big.Int)
+	}
+
+	var requests []*WithdrawalRequest
+	i := head.Int64()
+	for ; i < tail.Int64() && len(requests) < max; i++ {
+		bs, err := s.GetBytes(withdrawalQueueKey(i))
+		if err != nil {
+			return nil, err
+		}
+		wr, err := newWithdrawalRequestFromBytes(bs)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, wr)
+	}
+	if err := s.SetBigInt(varWithdrawalQueueHead, big.NewInt(i)); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// PeekWithdrawalRequests returns up to max requests from the head of the
+// queue, in FIFO order, without removing them, so a base-tx producer can
+// preview exactly what DequeueWithdrawalRequests will later drain for the
+// same queue state.
+func (s *State) PeekWithdrawalRequests(max int) ([]*WithdrawalRequest, error) {
+	head := s.GetBigInt(varWithdrawalQueueHead)
+	if head == nil {
+		head = new(big.Int)
+	}
+	tail := s.GetBigInt(varWithdrawalQueueTail)
+	if tail == nil {
+		tail = new(big.Int)
+	}
+
+	var requests []*WithdrawalRequest
+	for i := head.Int64(); i < tail.Int64() && len(requests) < max; i++ {
+		bs, err := s.GetBytes(withdrawalQueueKey(i))
+		if err != nil {
+			return nil, err
+		}
+		wr, err := newWithdrawalRequestFromBytes(bs)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, wr)
+	}
+	return requests, nil
+}
+
+// RequeueWithdrawalRequests pushes requests back onto the head of the
+// queue, preserving order, so entries left unprocessed this term (the
+// per-block cap, or a RequestedTermSeq that hasn't arrived yet) roll over
+// to the next one instead of being dropped.
+func (s *State) RequeueWithdrawalRequests(requests []*WithdrawalRequest) error {
+	if len(requests) == 0 {
+		return nil
+	}
+	head := s.GetBigInt(varWithdrawalQueueHead)
+	if head == nil {
+		head = new(big.Int)
+	}
+	newHead := head.Int64() - int64(len(requests))
+	if newHead < 0 {
+		return errors.InvalidStateError.New("WithdrawalQueueUnderflow")
+	}
+	for i, wr := range requests {
+		if err := s.SetBytes(withdrawalQueueKey(newHead+int64(i)), wr.Bytes()); err != nil {
+			return err
+		}
+	}
+	return s.SetBigInt(varWithdrawalQueueHead, big.NewInt(newHead))
+}
@@ -0,0 +1,47 @@
+package hvhstate
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/icon-project/goloop/common"
+)
+
+func TestWithdrawalRequest_BytesRoundtrip(t *testing.T) {
+	owner := common.MustNewAddressFromString("hx1234")
+	amount := big.NewInt(1000)
+
+	wr := NewWithdrawalRequest(owner, amount, 42)
+
+	wr2, err := newWithdrawalRequestFromBytes(wr.Bytes())
+	if err != nil {
+		t.Fatalf("newWithdrawalRequestFromBytes() error: %+v", err)
+	}
+	if !wr2.Owner().Equal(owner) {
+		t.Errorf("WithdrawalRequest.Owner() error")
+	}
+	if wr2.Amount().Cmp(amount) != 0 {
+		t.Errorf("WithdrawalRequest.Amount() error")
+	}
+	if wr2.RequestedTermSeq() != 42 {
+		t.Errorf("WithdrawalRequest.RequestedTermSeq() error")
+	}
+}
+
+func TestWithdrawalRequest_ToJSON(t *testing.T) {
+	owner := common.MustNewAddressFromString("hx1234")
+	amount := big.NewInt(500)
+
+	wr := NewWithdrawalRequest(owner, amount, 7)
+	json := wr.ToJSON()
+
+	if json["requestedTermSeq"] != int64(7) {
+		t.Errorf("ToJSON()[\"requestedTermSeq\"] error")
+	}
+}
+
+func TestWithdrawalQueueKey_UniquePerIndex(t *testing.T) {
+	if withdrawalQueueKey(0) == withdrawalQueueKey(1) {
+		t.Errorf("withdrawalQueueKey() should differ by index")
+	}
+}
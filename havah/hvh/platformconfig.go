@@ -13,4 +13,8 @@ type PlatformConfig struct {
 	PrivateReleaseCycle *common.HexInt64 `join:"privateReleaseCycle"` // 30 in term (1 month)
 	PrivateLockup       *common.HexInt64 `join:"privateLockup"`       // 360 in term
 	IssueLimit          *common.HexInt64 `join:"issueLimit"`
+
+	// ReleaseOracle is the SCORE address polled once per term for
+	// {major, minor, patch, rolloutBlock}; nil disables the version oracle.
+	ReleaseOracle *common.Address `json:"releaseOracle,omitempty"`
 }
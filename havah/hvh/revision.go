@@ -0,0 +1,206 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hvh
+
+import (
+	"math/big"
+
+	"github.com/icon-project/goloop/common"
+	"github.com/icon-project/goloop/common/errors"
+	"github.com/icon-project/goloop/havah/hvhmodule"
+	"github.com/icon-project/goloop/module"
+	"github.com/icon-project/goloop/service/state"
+)
+
+// checkReleaseOracle polls the configured release-oracle SCORE once per
+// term. If it announces a revision higher than both the chain's current one
+// and whatever is already pending, that revision and its RolloutBlock are
+// recorded so validators can start signaling readiness via
+// hvh_signalRevision, and any signals collected for a now-superseded
+// pending revision are discarded.
+func (es *ExtensionStateImpl) checkReleaseOracle(cc hvhmodule.CallContext, termSeq int64) error {
+	oracle := es.state.GetReleaseOracle()
+	if oracle == nil {
+		return nil
+	}
+
+	result, err := cc.CallReadOnly(oracle, "getReleaseInfo", nil)
+	if err != nil {
+		es.Logger().Warnf("checkReleaseOracle: oracle call failed: %+v", err)
+		return nil
+	}
+	ri, err := parseReleaseInfo(result)
+	if err != nil {
+		es.Logger().Warnf("checkReleaseOracle: malformed oracle response: %+v", err)
+		return nil
+	}
+
+	pending := ri.Revision()
+	if pending <= int(cc.Revision()) {
+		return nil
+	}
+
+	current := es.state.GetBigInt(hvhmodule.VarPendingRevision)
+	if current != nil {
+		if current.Int64() >= int64(pending) {
+			return nil
+		}
+		if oldTerm := es.state.GetBigInt(hvhmodule.VarPendingRevisionTerm); oldTerm != nil {
+			if err = es.state.ClearRevisionSignals(oldTerm.Int64()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err = es.state.SetBigInt(hvhmodule.VarPendingRevision, big.NewInt(int64(pending))); err != nil {
+		return err
+	}
+	if err = es.state.SetBigInt(hvhmodule.VarPendingRolloutBlock, big.NewInt(ri.RolloutBlock)); err != nil {
+		return err
+	}
+	if err = es.state.SetBigInt(hvhmodule.VarPendingRevisionTerm, big.NewInt(termSeq)); err != nil {
+		return err
+	}
+	onPendingRevisionEvent(cc, pending, ri.RolloutBlock)
+	return nil
+}
+
+// SignalRevision lets a validator record that it is ready for the pending
+// revision. It is exposed to governance as hvh_signalRevision. Only the
+// chain's current validators may signal: without this check an attacker
+// could call hvh_signalRevision from arbitrary sybil addresses to inflate
+// the count past the activation threshold.
+func (es *ExtensionStateImpl) SignalRevision(cc hvhmodule.CallContext) error {
+	pendingBI := es.state.GetBigInt(hvhmodule.VarPendingRevision)
+	if pendingBI == nil {
+		return errors.InvalidStateError.New("NoPendingRevision")
+	}
+	if !isValidator(cc, cc.From()) {
+		return errors.InvalidStateError.New("NotValidator")
+	}
+	term := es.state.GetBigInt(hvhmodule.VarPendingRevisionTerm)
+	if term == nil {
+		return errors.InvalidStateError.New("NoPendingRevision")
+	}
+	return es.state.SignalRevision(cc.From(), term.Int64())
+}
+
+func isValidator(cc hvhmodule.CallContext, addr module.Address) bool {
+	for _, v := range cc.GetValidators() {
+		if v.Address().Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// maybeActivateRevision advances module's Revision once BlockHeight has
+// reached RolloutBlock and at least RevisionSupermajorityNum/Denom of the
+// current validators have signaled the pending revision. It runs from
+// onTermStart so activation is checked at the same cadence issuance is.
+func (es *ExtensionStateImpl) maybeActivateRevision(cc hvhmodule.CallContext) error {
+	pendingBI := es.state.GetBigInt(hvhmodule.VarPendingRevision)
+	if pendingBI == nil {
+		return nil
+	}
+	rolloutBI := es.state.GetBigInt(hvhmodule.VarPendingRolloutBlock)
+	if rolloutBI == nil || cc.BlockHeight() < rolloutBI.Int64() {
+		return nil
+	}
+	termBI := es.state.GetBigInt(hvhmodule.VarPendingRevisionTerm)
+	if termBI == nil {
+		return nil
+	}
+
+	pending := int(pendingBI.Int64())
+	term := termBI.Int64()
+	signaled, err := es.state.RevisionSignalCount(term)
+	if err != nil {
+		return err
+	}
+	validators := cc.GetValidators()
+	if len(validators) == 0 || signaled*hvhmodule.RevisionSupermajorityDenom < len(validators)*hvhmodule.RevisionSupermajorityNum {
+		return nil
+	}
+
+	if err = cc.SetRevision(pending); err != nil {
+		return err
+	}
+	if err = es.state.ClearRevisionSignals(term); err != nil {
+		return err
+	}
+	if err = es.state.SetBigInt(hvhmodule.VarPendingRevision, nil); err != nil {
+		return err
+	}
+	if err = es.state.SetBigInt(hvhmodule.VarPendingRolloutBlock, nil); err != nil {
+		return err
+	}
+	return es.state.SetBigInt(hvhmodule.VarPendingRevisionTerm, nil)
+}
+
+// toInt64 extracts an int64 from a SCORE read-only call result value, which
+// comes back as a *common.HexInt (the same type chainscore_havah.go's own
+// Ex_ methods accept for numeric parameters) rather than a native int64.
+func toInt64(v interface{}) (int64, bool) {
+	switch t := v.(type) {
+	case *common.HexInt:
+		return t.Value().Int64(), true
+	case *big.Int:
+		return t.Int64(), true
+	case int64:
+		return t, true
+	default:
+		return 0, false
+	}
+}
+
+func parseReleaseInfo(result interface{}) (*hvhmodule.ReleaseInfo, error) {
+	m, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, errors.Errorf("unexpected oracle result type %T", result)
+	}
+	major, ok := toInt64(m["major"])
+	if !ok {
+		return nil, errors.Errorf("unexpected type for major: %T", m["major"])
+	}
+	minor, ok := toInt64(m["minor"])
+	if !ok {
+		return nil, errors.Errorf("unexpected type for minor: %T", m["minor"])
+	}
+	patch, ok := toInt64(m["patch"])
+	if !ok {
+		return nil, errors.Errorf("unexpected type for patch: %T", m["patch"])
+	}
+	rollout, ok := toInt64(m["rolloutBlock"])
+	if !ok {
+		return nil, errors.Errorf("unexpected type for rolloutBlock: %T", m["rolloutBlock"])
+	}
+	return &hvhmodule.ReleaseInfo{
+		Major:        int(major),
+		Minor:        int(minor),
+		Patch:        int(patch),
+		RolloutBlock: rollout,
+	}, nil
+}
+
+func onPendingRevisionEvent(cc hvhmodule.CallContext, revision int, rolloutBlock int64) {
+	cc.OnEvent(
+		state.SystemAddress,
+		[][]byte{[]byte("PendingRevision(int,int)")},
+		[][]byte{big.NewInt(int64(revision)).Bytes(), big.NewInt(rolloutBlock).Bytes()},
+	)
+}
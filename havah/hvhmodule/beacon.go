@@ -0,0 +1,114 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hvhmodule
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"sort"
+
+	"github.com/icon-project/goloop/common/crypto"
+)
+
+const (
+	VarBeaconRoundStart = "beacon_round_start"
+	VarLastBeaconEntry  = "last_beacon_entry"
+)
+
+// RewardSetBonusSize is how many Planets SelectIndices picks for a term's
+// bonus reward set. It is a fixed default rather than a governance
+// parameter for now; promote it to one if that turns out to be needed.
+const RewardSetBonusSize = 10
+
+// BeaconAPI is a drand-style round-based randomness source that returns
+// the entry for a given round. Term reward selection does not call it; it
+// reads entries through CallContext.BeaconEntry.
+type BeaconAPI interface {
+	Entry(round uint64) ([]byte, error)
+}
+
+// BeaconNetwork binds a BeaconAPI to the round at which the chain started
+// relying on it. PreviousEntry chains this network's first entry back to
+// the last entry of whatever network preceded it, so a light client can
+// verify the whole beacon history without re-fetching it.
+type BeaconNetwork struct {
+	StartRound    uint64
+	Beacon        BeaconAPI
+	PreviousEntry []byte
+}
+
+// BeaconNetworks is sorted by StartRound ascending.
+type BeaconNetworks []BeaconNetwork
+
+// BeaconNetworkForRound binary-searches for the network active at round.
+func (bns BeaconNetworks) BeaconNetworkForRound(round uint64) *BeaconNetwork {
+	idx := sort.Search(len(bns), func(i int) bool {
+		return bns[i].StartRound > round
+	}) - 1
+	if idx < 0 {
+		return nil
+	}
+	return &bns[idx]
+}
+
+// nilBeacon is a no-op BeaconAPI for genesis and any block before a beacon
+// network has been activated, so chains that have not configured one yet
+// keep working unchanged.
+type nilBeacon struct{}
+
+func (nilBeacon) Entry(round uint64) ([]byte, error) {
+	return nil, nil
+}
+
+// NilBeacon is the BeaconAPI used pre-activation.
+var NilBeacon BeaconAPI = nilBeacon{}
+
+// RewardSetSeed derives the seed SelectIndices draws a term's reward-set
+// selection from, binding it to both entry and termSeq so the same entry
+// reused across domains (e.g. by BeaconRandomness in the consensus package)
+// never collides with a selection from a different term.
+func RewardSetSeed(entry []byte, termSeq int64) []byte {
+	buf := make([]byte, 0, len(entry)+8)
+	var tb [8]byte
+	binary.BigEndian.PutUint64(tb[:], uint64(termSeq))
+	buf = append(buf, tb[:]...)
+	buf = append(buf, entry...)
+	return crypto.SHA3Sum256(buf)
+}
+
+// SelectIndices deterministically picks count distinct indices out of
+// [0, total), using a local random source seeded from seed, so every
+// validator computes the same "active reward set" from the same beacon
+// entry without a separate vote.
+func SelectIndices(seed []byte, total, count int) []int {
+	if total <= 0 || count <= 0 {
+		return nil
+	}
+	if count > total {
+		count = total
+	}
+	rng := rand.New(rand.NewSource(int64(binary.BigEndian.Uint64(seed[:8]))))
+	pool := make([]int, total)
+	for i := range pool {
+		pool[i] = i
+	}
+	rng.Shuffle(total, func(i, j int) { pool[i], pool[j] = pool[j], pool[i] })
+	selected := make([]int, count)
+	copy(selected, pool[:count])
+	sort.Ints(selected)
+	return selected
+}
@@ -0,0 +1,52 @@
+package hvhmodule
+
+import (
+	"testing"
+)
+
+func TestSelectIndicesIsDeterministic(t *testing.T) {
+	seed := RewardSetSeed([]byte("entry"), 7)
+
+	a := SelectIndices(seed, 100, 10)
+	b := SelectIndices(seed, 100, 10)
+
+	if len(a) != 10 || len(b) != 10 {
+		t.Fatalf("expected 10 indices, got %d and %d", len(a), len(b))
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Errorf("SelectIndices is not deterministic: %v != %v", a, b)
+		}
+	}
+}
+
+func TestSelectIndicesDiffersByTerm(t *testing.T) {
+	entry := []byte("entry")
+	a := SelectIndices(RewardSetSeed(entry, 1), 100, 10)
+	b := SelectIndices(RewardSetSeed(entry, 2), 100, 10)
+
+	same := true
+	for i := range a {
+		if a[i] != b[i] {
+			same = false
+			break
+		}
+	}
+	if same {
+		t.Errorf("expected different terms to select a different subset, got the same one: %v", a)
+	}
+}
+
+func TestSelectIndicesBounds(t *testing.T) {
+	seed := RewardSetSeed([]byte("entry"), 1)
+
+	if got := SelectIndices(seed, 0, 10); got != nil {
+		t.Errorf("expected no selection for total=0, got %v", got)
+	}
+	if got := SelectIndices(seed, 10, 0); got != nil {
+		t.Errorf("expected no selection for count=0, got %v", got)
+	}
+	if got := SelectIndices(seed, 5, 10); len(got) != 5 {
+		t.Errorf("expected count to be capped at total=5, got %d indices", len(got))
+	}
+}
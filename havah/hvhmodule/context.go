@@ -26,6 +26,19 @@ type WorldContext interface {
 	GetScoreOwner(score module.Address) (module.Address, error)
 	SetScoreOwner(from module.Address, score module.Address, owner module.Address) error
 	GetAccountState(id []byte) state.AccountState
+	// BeaconEntry returns the randomness beacon entry for round, or nil if
+	// no beacon network is active yet for the current height.
+	BeaconEntry(round uint64) ([]byte, error)
+	// GetValidators returns the validator set active for the current block,
+	// the read-side counterpart of SetValidators.
+	GetValidators() []module.Validator
+	// SetRevision advances the chain's revision. Used by the release-oracle
+	// mechanism once a pending revision has been signaled by a supermajority
+	// of validators.
+	SetRevision(value int) error
+	// CallReadOnly invokes method on the SCORE at to without charging step
+	// or allowing state changes, used to poll the release oracle.
+	CallReadOnly(to module.Address, method string, params map[string]interface{}) (interface{}, error)
 }
 
 type CallContext interface {
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hvhmodule
+
+const (
+	VarReleaseOracle       = "release_oracle"
+	VarPendingRevision     = "pending_revision"
+	VarPendingRolloutBlock = "pending_rollout_block"
+	// VarPendingRevisionTerm is the term sequence at which the currently
+	// pending revision was announced. Validator signals are tracked per
+	// this term rather than per revision number, so a signal collected
+	// while a revision was pending always counts toward that same
+	// activation attempt even if the revision number is reused later.
+	VarPendingRevisionTerm = "pending_revision_term"
+)
+
+// RevisionSupermajorityNum/Denom is the fraction of validators that must
+// have signaled a pending revision for it to activate automatically,
+// mirroring BFT's usual 2/3 threshold. The count is checked at each term
+// start once BlockHeight has reached RolloutBlock; signals recorded after
+// RolloutBlock count as well.
+const (
+	RevisionSupermajorityNum   = 2
+	RevisionSupermajorityDenom = 3
+)
+
+// ReleaseInfo is the {Major, Minor, Patch, RolloutBlock} tuple read from the
+// release-oracle SCORE, mirroring a semver-style upgrade announcement.
+type ReleaseInfo struct {
+	Major        int
+	Minor        int
+	Patch        int
+	RolloutBlock int64
+}
+
+// Revision returns the goloop module.Revision this release activates.
+// module.Revision is a small, sequential integer that indexes directly into
+// goloop's revision-gated feature tables, not a semver value, so packing
+// Major/Minor/Patch together (e.g. "10203") would land outside that space
+// and not behave like a real revision bump. Patch, the oracle's most
+// granular field, is used as the revision number directly; Major/Minor
+// identify the broader release for humans and logging and are not
+// otherwise consumed here.
+func (ri *ReleaseInfo) Revision() int {
+	return ri.Patch
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright 2020 ICON Foundation
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package v3
+
+import (
+	"github.com/icon-project/goloop/server/jsonrpc"
+)
+
+// AdminAPIPath is the HTTP path AdminMethodRepository is meant to be bound
+// to by the server's router, declared here so whatever mounts it doesn't
+// have to guess. This package only builds the method repository; it does
+// not own the HTTP mux that binds paths to handlers.
+const AdminAPIPath = "/api/v3/admin"
+
+// AdminOrigins is the allowlist of local origins permitted to call the
+// admin_* namespace's mutating methods (admin_addSeed, admin_removeSeed,
+// admin_setLogLevel). It starts empty, rejecting every mutating call, until
+// SetAdminOrigins is called with the chain's configured allowlist, so
+// operators must opt in explicitly rather than getting an open admin
+// endpoint by default.
+var AdminOrigins = map[string]bool{}
+
+// SetAdminOrigins replaces AdminOrigins with the given allowlist. Callers
+// should invoke this once at startup with the origins from chain config;
+// passing nil or an empty slice leaves every mutating admin_* call rejected.
+func SetAdminOrigins(origins []string) {
+	m := make(map[string]bool, len(origins))
+	for _, o := range origins {
+		m[o] = true
+	}
+	AdminOrigins = m
+}
+
+// AdminMethodRepository registers the admin_* namespace. It is kept separate
+// from MethodRepository() so it can be bound to its own HTTP path
+// (AdminAPIPath) and left unregistered entirely in production deployments
+// that don't want a node management endpoint exposed.
+func AdminMethodRepository() *jsonrpc.MethodRepository {
+	mr := jsonrpc.NewMethodRepository()
+
+	mr.RegisterMethod("admin_nodeInfo", adminNodeInfo)
+	mr.RegisterMethod("admin_peers", adminPeers)
+	mr.RegisterMethod("admin_addSeed", adminAddSeed)
+	mr.RegisterMethod("admin_removeSeed", adminRemoveSeed)
+	mr.RegisterMethod("admin_setLogLevel", adminSetLogLevel)
+
+	return mr
+}
+
+func checkAdminOrigin(ctx *jsonrpc.Context) error {
+	origin := ctx.Origin()
+	if !AdminOrigins[origin] {
+		return jsonrpc.ErrUnauthorized()
+	}
+	return nil
+}
+
+// adminNodeInfo returns the node's enode-style identity, listen address and
+// configured seed peers, mirroring admin_nodeInfo from the reference doc.
+func adminNodeInfo(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
+	if !params.IsEmpty() {
+		return nil, jsonrpc.ErrInvalidParams()
+	}
+
+	chain, _ := ctx.Chain()
+	nm := chain.NetworkManager()
+
+	result := map[string]interface{}{
+		"id":        nm.GetID(),
+		"listen":    nm.GetListenAddress(),
+		"seedPeers": nm.GetSeedPeers(),
+	}
+	return result, nil
+}
+
+// adminPeers returns the connected peers with their last known heights, and
+// the consensus round currently in progress, so operators can tell whether a
+// validator is keeping up with its peers without restarting it.
+func adminPeers(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
+	if !params.IsEmpty() {
+		return nil, jsonrpc.ErrInvalidParams()
+	}
+
+	chain, _ := ctx.Chain()
+	nm := chain.NetworkManager()
+	cs := chain.Consensus()
+
+	peers := make([]map[string]interface{}, 0)
+	for _, p := range nm.GetPeers() {
+		peers = append(peers, map[string]interface{}{
+			"id":     p.ID(),
+			"height": p.Height(),
+		})
+	}
+
+	result := map[string]interface{}{
+		"peers": peers,
+		"round": cs.GetStatus().Round,
+	}
+	return result, nil
+}
+
+type adminSeedParam struct {
+	Address string `json:"address"`
+}
+
+// adminAddSeed adds addr as a seed peer of the running chain. Restricted to
+// AdminOrigins since it changes live network configuration.
+func adminAddSeed(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
+	if err := checkAdminOrigin(ctx); err != nil {
+		return nil, err
+	}
+	var param adminSeedParam
+	if err := params.Convert(&param); err != nil {
+		return nil, err
+	}
+
+	chain, _ := ctx.Chain()
+	nm := chain.NetworkManager()
+	if err := nm.AddSeedPeer(param.Address); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// adminRemoveSeed removes addr from the running chain's seed peers.
+// Restricted to AdminOrigins since it changes live network configuration.
+func adminRemoveSeed(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
+	if err := checkAdminOrigin(ctx); err != nil {
+		return nil, err
+	}
+	var param adminSeedParam
+	if err := params.Convert(&param); err != nil {
+		return nil, err
+	}
+
+	chain, _ := ctx.Chain()
+	nm := chain.NetworkManager()
+	if err := nm.RemoveSeedPeer(param.Address); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+type adminLogLevelParam struct {
+	Level string `json:"level"`
+}
+
+// adminSetLogLevel changes the chain's log level at runtime. Restricted to
+// AdminOrigins for the same reason as admin_addSeed/admin_removeSeed.
+func adminSetLogLevel(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
+	if err := checkAdminOrigin(ctx); err != nil {
+		return nil, err
+	}
+	var param adminLogLevelParam
+	if err := params.Convert(&param); err != nil {
+		return nil, err
+	}
+
+	chain, _ := ctx.Chain()
+	if err := chain.SetLogLevel(param.Level); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
@@ -31,6 +31,8 @@ func MethodRepository() *jsonrpc.MethodRepository {
 	mr.RegisterMethod("icx_getVotesByHeight", getVotesByHeight)
 	mr.RegisterMethod("icx_getProofForResult", getProofForResult)
 
+	mr.RegisterMethod("icx_getScoreStatus", getScoreStatus)
+
 	return mr
 }
 
@@ -79,7 +81,29 @@ func getBlockByHash(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{},
 }
 
 func call(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
-	return nil, nil
+	var param CallParam
+	if err := params.Convert(&param); err != nil {
+		return nil, err
+	}
+
+	chain, _ := ctx.Chain()
+	bm := chain.BlockManager()
+	sm := chain.ServiceManager()
+
+	block, err := bm.GetLastBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	bs, err := json.Marshal(&param)
+	if err != nil {
+		return nil, err
+	}
+	result, err := sm.Call(block.Result(), block.NextValidators(), bs, block)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 func getBalance(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
@@ -104,16 +128,39 @@ func getScoreApi(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, err
 	if err := params.Convert(&param); err != nil {
 		return nil, err
 	}
-	// TODO : service interface required
-	return nil, nil
+
+	chain, _ := ctx.Chain()
+	bm := chain.BlockManager()
+	sm := chain.ServiceManager()
+
+	block, err := bm.GetLastBlock()
+	if err != nil {
+		return nil, err
+	}
+	info, err := sm.GetAPIInfo(block.Result(), param.Address.Address())
+	if err != nil {
+		return nil, err
+	}
+	return info.ToJSON(3)
 }
 
 func getTotalSupply(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
 	if !params.IsEmpty() {
 		return nil, jsonrpc.ErrInvalidParams()
 	}
-	// TODO : service interface required
-	return nil, nil
+
+	chain, _ := ctx.Chain()
+	bm := chain.BlockManager()
+	sm := chain.ServiceManager()
+
+	block, err := bm.GetLastBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var supply common.HexInt
+	supply.Set(sm.GetTotalSupply(block.Result()))
+	return supply, nil
 }
 
 func getTransactionResult(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
@@ -180,11 +227,11 @@ func getDataByHash(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, e
 
 	bucket, err := dbm.GetBucket(db.BytesByHash)
 	if err != nil {
-
+		return nil, err
 	}
 	value, err := bucket.Get(param.Hash.Bytes())
 	if err != nil {
-
+		return nil, err
 	}
 	if value == nil {
 		return nil, jsonrpc.ErrInvalidParams()
@@ -240,4 +287,57 @@ func getProofForResult(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{
 	proofs, _ := receipts.GetProof(int(param.Index.Value()))
 
 	return proofs, nil
-}
\ No newline at end of file
+}
+
+// getScoreStatus is the Havah-specific governance view of a SCORE: owner,
+// deployer, current/next audit status, and the chain's current revision,
+// since Havah operators manage SCORE deploys through governance
+// rather than a permissionless audit queue.
+func getScoreStatus(ctx *jsonrpc.Context, params *jsonrpc.Params) (interface{}, error) {
+	var param ScoreAddressParam
+	if err := params.Convert(&param); err != nil {
+		return nil, err
+	}
+
+	chain, _ := ctx.Chain()
+	bm := chain.BlockManager()
+	sm := chain.ServiceManager()
+
+	block, err := bm.GetLastBlock()
+	if err != nil {
+		return nil, err
+	}
+	addr := param.Address.Address()
+
+	wc, err := sm.GetContext(block.Result(), block.NextValidators())
+	if err != nil {
+		return nil, err
+	}
+	owner, err := wc.GetScoreOwner(addr)
+	if err != nil {
+		return nil, err
+	}
+	status, err := sm.GetSCOREStatus(block.Result(), addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// deployer/current/next are called out explicitly, rather than left to
+	// whatever GetSCOREStatus happens to include, so a caller auditing a
+	// SCORE's deploy/update history can always rely on their presence.
+	result := map[string]interface{}{
+		"owner":    owner,
+		"deployer": status["deployer"],
+		"current":  status["current"],
+		"next":     status["next"],
+		"revision": wc.Revision(),
+	}
+	for k, v := range status {
+		switch k {
+		case "deployer", "current", "next":
+			continue
+		}
+		result[k] = v
+	}
+	return result, nil
+}